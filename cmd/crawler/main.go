@@ -2,18 +2,38 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/vfunin/crawler/internal/canonical"
+	"github.com/vfunin/crawler/internal/certs"
+	"github.com/vfunin/crawler/internal/checkpoint"
 	"github.com/vfunin/crawler/internal/config"
 	"github.com/vfunin/crawler/internal/crawler"
+	"github.com/vfunin/crawler/internal/fetcher"
+	"github.com/vfunin/crawler/internal/linkcheck"
+	"github.com/vfunin/crawler/internal/orphans"
+	"github.com/vfunin/crawler/internal/politeness"
 	"github.com/vfunin/crawler/internal/printer"
+	"github.com/vfunin/crawler/internal/retry"
+	"github.com/vfunin/crawler/internal/robots"
+	"github.com/vfunin/crawler/internal/sitemap"
 
 	"github.com/rs/zerolog/log"
 )
 
+// exitBrokenLinks - exit code of the link check mode when broken links are found
+const exitBrokenLinks = 1
+
 func main() {
+	os.Exit(run())
+}
+
+// run - crawls according to the configuration and returns the exit code
+func run() int {
 	cfg := handleConfiguration()
 
 	log.Debug().Msgf("starting with config: %s", cfg)
@@ -24,18 +44,204 @@ func main() {
 
 	log.Trace().Msg("start crawler goroutine")
 
-	c := crawler.New(cfg.MaxDepth(), cfg.Timeout())
-	go c.Crawl(ctx, cancel, cfg.URL(), cfg.WithPanic(), 0, errCh)
+	store := openCheckpoint(cfg)
+	if store != nil {
+		defer store.Close()
+	}
+
+	transport := newTransport(cfg)
+	c := newCrawler(cfg, store, newFetcher(ctx, cfg, transport), transport)
+	crawl(ctx, cancel, cfg, c, errCh)
 
 	log.Trace().Msg("start printer goroutine")
 
-	p := printer.New(ctx, cancel, cfg, c, errCh)
-	go p.Print()
+	var (
+		report linkcheck.Report
+		opts   []printer.Option
+	)
+
+	if cfg.LinkCheck().Enabled {
+		report = linkcheck.New()
+		opts = append(opts, printer.WithReport(report))
+	}
+
+	if days := cfg.TLS().ExpiryDays; days > 0 {
+		opts = append(opts, printer.WithSummary(certs.New(days, time.Now())))
+	}
+
+	if cfg.Sitemaps().Enabled() {
+		opts = append(opts, printer.WithSummary(orphans.New()))
+	}
+
+	if out := cfg.SitemapOutput(); out.Enabled() {
+		opts = append(opts, printer.WithSink(printer.NewSitemap(out)))
+	}
+
+	p := printer.New(cancel, cfg, c, errCh, opts...)
+	printed := make(chan struct{})
 
-	listenChannels(ctx, cancel, errCh, cfg.DepthIncStep(), c)
+	go func() {
+		p.Print()
+		close(printed)
+	}()
+
+	listenChannels(cancel, errCh, printed, cfg.DepthIncStep(), c)
+
+	if report != nil && len(report.Broken()) > 0 {
+		return exitBrokenLinks
+	}
+
+	return 0
 }
 
-func listenChannels(ctx context.Context, cancel context.CancelFunc, errCh <-chan error, depthStep int, c crawler.Crawler) {
+// crawl - starts crawling from every seed, the crawler counts the first seed itself,
+// without seeds only the restored crawl is continued
+func crawl(ctx context.Context, cancel context.CancelFunc, cfg config.Configuration, c crawler.Crawler, errCh chan<- error) {
+	seeds := cfg.Seeds()
+	if len(seeds) == 0 {
+		go c.Crawl(ctx, cancel, "", cfg.WithPanic(), 0, errCh)
+
+		return
+	}
+
+	for range seeds[1:] {
+		c.IncCnt()
+	}
+
+	for _, seed := range seeds {
+		go c.Crawl(ctx, cancel, seed.URL, cfg.WithPanic(), 0, errCh)
+	}
+}
+
+func openCheckpoint(cfg config.Configuration) checkpoint.Store {
+	if cfg.CheckpointDir() == "" {
+		return nil
+	}
+
+	store, err := checkpoint.Open(cfg.CheckpointDir(), cfg.Resume())
+	if err != nil {
+		log.Fatal().Err(err).Msg("checkpoint error")
+	}
+
+	// the configuration is saved for the resumed crawl, with the flags it was resumed with
+	snapshot, err := cfg.Snapshot()
+	if err == nil {
+		err = store.SaveConfig(snapshot)
+	}
+
+	if err != nil {
+		log.Fatal().Err(err).Msg("checkpoint error")
+	}
+
+	return store
+}
+
+// newTransport - returns the connections shared by the fetcher and the robots.txt client
+func newTransport(cfg config.Configuration) http.RoundTripper {
+	proxyCfg, err := cfg.Proxy().Resolve()
+	if err != nil {
+		log.Fatal().Err(err).Msg("config error")
+	}
+
+	proxy, err := fetcher.NewProxy(proxyCfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("config error")
+	}
+
+	t := fetcher.NewTransport(cfg.Transport())
+	t.Proxy = proxy
+
+	rt, err := fetcher.ConfigureTLS(t, cfg.TLS())
+	if err != nil {
+		log.Fatal().Err(err).Msg("config error")
+	}
+
+	return rt
+}
+
+// newFetcher - returns the fetcher shared by the crawl, logged in if the login is configured
+func newFetcher(ctx context.Context, cfg config.Configuration, transport http.RoundTripper) fetcher.Fetcher {
+	request, err := cfg.Request().Resolve()
+	if err != nil {
+		log.Fatal().Err(err).Msg("config error")
+	}
+
+	login, err := cfg.Login().Resolve()
+	if err != nil {
+		log.Fatal().Err(err).Msg("config error")
+	}
+
+	f := fetcher.New(time.Duration(cfg.Timeout())*time.Second,
+		fetcher.WithRoundTripper(transport),
+		fetcher.WithUserAgent(cfg.UserAgent()),
+		fetcher.WithRequest(request),
+		fetcher.WithLogin(login),
+		fetcher.WithContent(cfg.Content()),
+		fetcher.WithLinkRules(cfg.Links().Rules),
+	)
+
+	if login.Enabled() {
+		if err = f.Login(ctx); err != nil {
+			log.Fatal().Err(err).Msg("login error")
+		}
+
+		log.Info().Msgf("logged in at %s", login.URL)
+	}
+
+	return f
+}
+
+func newCrawler(cfg config.Configuration, store checkpoint.Store, f fetcher.Fetcher, transport http.RoundTripper) crawler.Crawler {
+	p := politeness.New(cfg.Politeness())
+	opts := []crawler.Option{
+		crawler.WithWorkers(cfg.Workers()),
+		crawler.WithFetcher(f),
+		crawler.WithRetry(retry.New(cfg.Retry())),
+		crawler.WithBreaker(retry.NewBreaker(cfg.Retry())),
+		crawler.WithPoliteness(p),
+		crawler.WithScope(cfg.Scope()),
+		crawler.WithSeeds(cfg.Seeds()),
+		crawler.WithCanonicalizer(canonical.New(cfg.Canonicalization())),
+		crawler.WithLinks(cfg.Links()),
+		crawler.WithDirectives(cfg.Directives()),
+	}
+
+	if cfg.LinkCheck().Enabled {
+		opts = append(opts, crawler.WithLinkCheck(cfg.LinkCheck()))
+	}
+
+	if store != nil {
+		opts = append(opts, crawler.WithCheckpoint(store))
+	}
+
+	timeout := time.Duration(cfg.Timeout()) * time.Second
+
+	var r robots.Robots
+
+	if !cfg.IgnoreRobots() {
+		r = robots.New(cfg.UserAgent(), timeout, p.SetCrawlDelay, robots.WithRoundTripper(transport))
+		opts = append(opts, crawler.WithRobots(r))
+	}
+
+	if sm := cfg.Sitemaps(); sm.Enabled() {
+		if r == nil {
+			// robots.txt is read only for its sitemaps, its rules and crawl delay are ignored
+			r = robots.New(cfg.UserAgent(), timeout, nil, robots.WithRoundTripper(transport))
+		}
+
+		s := sitemap.New(sm, timeout,
+			sitemap.WithRoundTripper(transport),
+			sitemap.WithUserAgent(cfg.UserAgent()),
+			sitemap.WithRobots(r),
+		)
+		opts = append(opts, crawler.WithSitemaps(s))
+	}
+
+	return crawler.New(cfg.MaxDepth(), cfg.Timeout(), opts...)
+}
+
+// listenChannels - handles errors and signals until all results are printed
+func listenChannels(cancel context.CancelFunc, errCh <-chan error, printed <-chan struct{}, depthStep int, c crawler.Crawler) {
 	stopCh := make(chan os.Signal, 1)
 	signal.Notify(stopCh, syscall.SIGINT)
 
@@ -44,8 +250,9 @@ func listenChannels(ctx context.Context, cancel context.CancelFunc, errCh <-chan
 
 	for {
 		select {
-		case <-ctx.Done():
+		case <-printed:
 			log.Info().Msg("crawling done")
+			cancel()
 
 			return
 		case err := <-errCh:
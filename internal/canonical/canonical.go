@@ -0,0 +1,244 @@
+package canonical
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/vfunin/crawler/internal/config"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/idna"
+)
+
+const upperHex = "0123456789ABCDEF"
+
+var defaultPorts = map[string]string{ //nolint:gochecknoglobals
+	"http":  "80",
+	"https": "443",
+}
+
+var indexFiles = []string{"index.html", "index.htm"} //nolint:gochecknoglobals
+
+type Canonicalizer interface {
+	Canonicalize(uri string) (string, error)
+}
+
+type canonicalizer struct {
+	cfg            config.Canonicalization
+	trackingParams []string
+}
+
+func New(cfg config.Canonicalization) Canonicalizer {
+	c := &canonicalizer{cfg: cfg, trackingParams: nil}
+
+	if !cfg.KeepTrackingParams {
+		c.trackingParams = cfg.TrackingParams
+		if len(c.trackingParams) == 0 {
+			c.trackingParams = config.DefaultTrackingParams
+		}
+	}
+
+	return c
+}
+
+// Canonicalize - returns the canonical form of a http(s) url, other urls are returned as is
+func (c *canonicalizer) Canonicalize(uri string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return "", errors.Wrap(err, "canonical url parsing")
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if _, ok := defaultPorts[scheme]; !ok || u.Opaque != "" {
+		return uri, nil
+	}
+
+	host := c.host(scheme, u)
+
+	var b strings.Builder
+
+	b.WriteString(scheme)
+	b.WriteString("://")
+
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+
+	b.WriteString(host)
+	b.WriteString(c.path(u.EscapedPath()))
+
+	if query := c.query(u.RawQuery); query != "" {
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+
+	return b.String(), nil
+}
+
+// host - lowercases the host, converts IDN to punycode and removes the default port,
+// hosts which are not valid IDNs (e.g. with underscores) are only lowercased
+func (c *canonicalizer) host(scheme string, u *url.URL) string {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+
+	if net.ParseIP(host) == nil {
+		if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+			host = ascii
+		}
+	}
+
+	if port == defaultPorts[scheme] {
+		port = ""
+	}
+
+	if port != "" {
+		return net.JoinHostPort(host, port)
+	}
+
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+
+	return host
+}
+
+func (c *canonicalizer) path(path string) string {
+	path = normalizeEscapes(path)
+
+	if c.cfg.FoldIndex {
+		for _, index := range indexFiles {
+			if strings.HasSuffix(path, "/"+index) {
+				path = strings.TrimSuffix(path, index)
+
+				break
+			}
+		}
+	}
+
+	if c.cfg.FoldTrailingSlash && len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	return path
+}
+
+// query - normalizes escapes, removes tracking parameters and sorts the parameters
+func (c *canonicalizer) query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params := strings.Split(rawQuery, "&")
+	result := make([]string, 0, len(params))
+
+	for _, param := range params {
+		if param == "" {
+			continue
+		}
+
+		param = normalizeEscapes(param)
+
+		name := param
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			name = param[:i]
+		}
+
+		if c.isTracking(name) {
+			continue
+		}
+
+		result = append(result, param)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return paramName(result[i]) < paramName(result[j])
+	})
+
+	return strings.Join(result, "&")
+}
+
+func (c *canonicalizer) isTracking(name string) bool {
+	if decoded, err := url.QueryUnescape(name); err == nil {
+		name = decoded
+	}
+
+	name = strings.ToLower(name)
+
+	for _, p := range c.trackingParams {
+		p = strings.ToLower(p)
+
+		if strings.HasSuffix(p, "*") && strings.HasPrefix(name, strings.TrimSuffix(p, "*")) || name == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+func paramName(param string) string {
+	if i := strings.IndexByte(param, '='); i >= 0 {
+		return param[:i]
+	}
+
+	return param
+}
+
+// normalizeEscapes - uppercases percent-encoded triplets and decodes the ones of unreserved characters
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+
+			continue
+		}
+
+		decoded := unhex(s[i+1])<<4 | unhex(s[i+2])
+
+		if isUnreserved(decoded) {
+			b.WriteByte(decoded)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(upperHex[decoded>>4])
+			b.WriteByte(upperHex[decoded&0x0f])
+		}
+
+		i += 2
+	}
+
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
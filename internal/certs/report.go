@@ -0,0 +1,100 @@
+package certs
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/vfunin/crawler/internal/crawler"
+
+	"github.com/pkg/errors"
+)
+
+const dateLayout = "2006-01-02"
+
+// Host - server certificate of a host
+type Host struct {
+	Host    string
+	Issuer  string
+	Expires time.Time
+}
+
+type Report interface {
+	Add(r crawler.Result)
+	Expiring() []Host
+	Write(w io.Writer) error
+}
+
+type report struct {
+	days  int
+	now   time.Time
+	hosts map[string]Host
+}
+
+// New - returns the report of the certificates expiring within days after now
+func New(days int, now time.Time) Report {
+	return &report{days: days, now: now, hosts: make(map[string]Host)}
+}
+
+// Add - remembers the certificate of the host the result has been fetched from
+func (r *report) Add(res crawler.Result) {
+	if res.CertExpires.IsZero() {
+		return
+	}
+
+	raw := res.FinalURL
+	if raw == "" {
+		raw = res.URL
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return
+	}
+
+	r.hosts[u.Host] = Host{Host: u.Host, Issuer: res.CertIssuer, Expires: res.CertExpires}
+}
+
+// Expiring - returns the certificates expiring within the days sorted by expiry
+func (r *report) Expiring() []Host {
+	deadline := r.now.AddDate(0, 0, r.days)
+	hosts := make([]Host, 0)
+
+	for _, h := range r.hosts {
+		if h.Expires.Before(deadline) {
+			hosts = append(hosts, h)
+		}
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].Expires.Equal(hosts[j].Expires) {
+			return hosts[i].Host < hosts[j].Host
+		}
+
+		return hosts[i].Expires.Before(hosts[j].Expires)
+	})
+
+	return hosts
+}
+
+// Write - outputs the expiring certificates, the already expired ones first
+func (r *report) Write(w io.Writer) (err error) {
+	hosts := r.Expiring()
+
+	for _, h := range hosts {
+		state := "expires"
+		if h.Expires.Before(r.now) {
+			state = "expired"
+		}
+
+		if _, err = fmt.Fprintf(w, "%s %s %s (%s)\n", h.Host, state, h.Expires.UTC().Format(dateLayout), h.Issuer); err != nil {
+			return errors.Wrap(err, "report writing")
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "%d of %d certificates expire within %d days\n", len(hosts), len(r.hosts), r.days)
+
+	return errors.Wrap(err, "report writing")
+}
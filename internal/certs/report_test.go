@@ -0,0 +1,39 @@
+package certs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/crawler"
+)
+
+func TestReport(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New(30, now)
+
+	r.Add(crawler.Result{URL: "http://go.test/"})
+	r.Add(crawler.Result{URL: "https://go.test/", CertIssuer: "Test CA", CertExpires: now.AddDate(0, 0, 10)})
+	r.Add(crawler.Result{URL: "https://go.test/page", CertIssuer: "Test CA", CertExpires: now.AddDate(0, 0, 10)})
+	r.Add(crawler.Result{
+		URL:         "http://old.test/",
+		FinalURL:    "https://old.test:8443/",
+		CertIssuer:  "Old CA",
+		CertExpires: now.AddDate(0, 0, -1),
+	})
+	r.Add(crawler.Result{URL: "https://fresh.test/", CertIssuer: "Test CA", CertExpires: now.AddDate(1, 0, 0)})
+
+	expiring := r.Expiring()
+	assert.Len(t, expiring, 2)
+	assert.Equal(t, "old.test:8443", expiring[0].Host)
+	assert.Equal(t, "go.test", expiring[1].Host)
+
+	var buf bytes.Buffer
+
+	assert.Nil(t, r.Write(&buf))
+	assert.Equal(t, `old.test:8443 expired 2021-12-31 (Old CA)
+go.test expires 2022-01-11 (Test CA)
+2 of 3 certificates expire within 30 days
+`, buf.String())
+}
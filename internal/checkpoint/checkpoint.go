@@ -0,0 +1,319 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	FileName    = "crawler.db"
+	dirPerm     = 0o755
+	filePerm    = 0o600
+	openTimeout = time.Second
+)
+
+var (
+	frontierBucket = []byte("frontier") //nolint:gochecknoglobals
+	visitedBucket  = []byte("visited")  //nolint:gochecknoglobals
+	emittedBucket  = []byte("emitted")  //nolint:gochecknoglobals
+	configBucket   = []byte("config")   //nolint:gochecknoglobals
+	configKey      = []byte("config")   //nolint:gochecknoglobals
+)
+
+// Task - pending frontier item
+type Task struct {
+	URL       string `json:"url"`
+	Depth     uint64 `json:"depth"`
+	WithPanic bool   `json:"with_panic"`
+	// Seed - url the task has been discovered from, used to restore the crawl scope
+	Seed string `json:"seed"`
+	// Referrer - url of the page linking to the task
+	Referrer string `json:"referrer,omitempty"`
+	// External - link out of the scope which is checked but not crawled
+	External bool `json:"external,omitempty"`
+	// Leaf - link of a page at the depth limit which is checked but not followed
+	Leaf bool `json:"leaf,omitempty"`
+	// Kind - kind of the link the task has been found as
+	Kind string `json:"kind,omitempty"`
+	// Source - sitemap or feed the task has been listed in
+	Source string `json:"source,omitempty"`
+	// Seq - position of the task in the frontier
+	Seq uint64 `json:"seq"`
+}
+
+// Store - crawl state, pushed tasks and visited urls are buffered and written in one transaction
+// before the next Done, Emit or Close, so the links of a page are saved at once
+type Store interface {
+	Push(t Task) error
+	Visit(url string) error
+	Done(url string) error
+	Emit(url string, result []byte) error
+	Emitted(url string) (bool, error)
+	Restore() (visited []string, pending []Task, err error)
+	// Results - returns the results output by the previous run to rebuild the reports of the resumed crawl
+	Results() ([][]byte, error)
+	// SaveConfig - saves the configuration of the run to resume it with
+	SaveConfig(cfg []byte) error
+	Close() error
+}
+
+type store struct {
+	db *bolt.DB
+	mu sync.Mutex
+	// writes - buffered pushes and visits in order
+	writes []write
+	// flushMu - orders the flushes, so a flush returns after the writes buffered before it are saved
+	flushMu sync.Mutex
+}
+
+// write - buffered push of the task or visit of the url
+type write struct {
+	task *Task
+	url  string
+}
+
+// Open - opens the checkpoint database in dir, the previous state is dropped unless resume is set
+func Open(dir string, resume bool) (Store, error) {
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, errors.Wrap(err, "checkpoint directory creation")
+	}
+
+	path := filepath.Join(dir, FileName)
+
+	if !resume {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, errors.Wrap(err, "checkpoint reset")
+		}
+	}
+
+	db, err := bolt.Open(path, filePerm, &bolt.Options{Timeout: openTimeout}) //nolint:exhaustivestruct
+	if err != nil {
+		return nil, errors.Wrap(err, "checkpoint opening")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{frontierBucket, visitedBucket, emittedBucket, configBucket} {
+			if _, bErr := tx.CreateBucketIfNotExists(name); bErr != nil {
+				return bErr
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+
+		return nil, errors.Wrap(err, "checkpoint buckets creation")
+	}
+
+	return &store{db: db, mu: sync.Mutex{}, writes: nil, flushMu: sync.Mutex{}}, nil
+}
+
+// LoadConfig - returns the configuration saved in the checkpoint in dir, nil if there is none
+func LoadConfig(dir string) (cfg []byte, err error) {
+	path := filepath.Join(dir, FileName)
+
+	if _, err = os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	db, err := bolt.Open(path, filePerm, &bolt.Options{Timeout: openTimeout, ReadOnly: true}) //nolint:exhaustivestruct
+	if err != nil {
+		return nil, errors.Wrap(err, "checkpoint opening")
+	}
+	defer db.Close()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(configBucket); bucket != nil {
+			cfg = append([]byte(nil), bucket.Get(configKey)...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "checkpoint reading")
+	}
+
+	if len(cfg) == 0 {
+		return nil, nil
+	}
+
+	return cfg, nil
+}
+
+// Push - adds the task to the frontier and its url to the visited set
+func (s *store) Push(t Task) error {
+	s.mu.Lock()
+	s.writes = append(s.writes, write{task: &t, url: t.URL})
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *store) Visit(url string) error {
+	s.mu.Lock()
+	s.writes = append(s.writes, write{task: nil, url: url})
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Done - removes the processed task from the frontier
+func (s *store) Done(url string) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	return s.batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(frontierBucket).Delete([]byte(url))
+	})
+}
+
+// Emit - records that the result of url has been output, the encoded result is kept for the resumed crawl
+func (s *store) Emit(url string, result []byte) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	return s.batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(emittedBucket).Put([]byte(url), result)
+	})
+}
+
+func (s *store) Emitted(url string) (emitted bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		emitted = tx.Bucket(emittedBucket).Get([]byte(url)) != nil
+
+		return nil
+	})
+
+	return emitted, errors.Wrap(err, "checkpoint reading")
+}
+
+// Restore - returns the visited set and the pending frontier of the previous run
+func (s *store) Restore() (visited []string, pending []Task, err error) {
+	if err = s.flush(); err != nil {
+		return nil, nil, err
+	}
+
+	err = s.db.View(func(tx *bolt.Tx) error {
+		if vErr := tx.Bucket(visitedBucket).ForEach(func(k, _ []byte) error {
+			visited = append(visited, string(k))
+
+			return nil
+		}); vErr != nil {
+			return vErr
+		}
+
+		return tx.Bucket(frontierBucket).ForEach(func(_, v []byte) error {
+			var t Task
+
+			if dErr := json.Unmarshal(v, &t); dErr != nil {
+				return dErr
+			}
+
+			pending = append(pending, t)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "checkpoint restoring")
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Seq < pending[j].Seq
+	})
+
+	return visited, pending, nil
+}
+
+func (s *store) Results() (results [][]byte, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(emittedBucket).ForEach(func(_, v []byte) error {
+			if len(v) > 0 {
+				results = append(results, append([]byte(nil), v...))
+			}
+
+			return nil
+		})
+	})
+
+	return results, errors.Wrap(err, "checkpoint reading")
+}
+
+func (s *store) SaveConfig(cfg []byte) error {
+	return s.batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(configBucket).Put(configKey, cfg)
+	})
+}
+
+func (s *store) Close() error {
+	if err := s.flush(); err != nil {
+		_ = s.db.Close()
+
+		return err
+	}
+
+	return s.db.Close()
+}
+
+// flush - writes the buffered pushes and visits in one transaction
+func (s *store) flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	writes := s.writes
+	s.writes = nil
+	s.mu.Unlock()
+
+	if len(writes) == 0 {
+		return nil
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		frontier, visited := tx.Bucket(frontierBucket), tx.Bucket(visitedBucket)
+
+		for _, w := range writes {
+			if err := visited.Put([]byte(w.url), nil); err != nil {
+				return err
+			}
+
+			if w.task == nil {
+				continue
+			}
+
+			seq, err := frontier.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			w.task.Seq = seq
+
+			value, err := json.Marshal(w.task)
+			if err != nil {
+				return err
+			}
+
+			if err = frontier.Put([]byte(w.url), value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return errors.Wrap(err, "checkpoint writing")
+}
+
+func (s *store) batch(fn func(tx *bolt.Tx) error) error {
+	return errors.Wrap(s.db.Batch(fn), "checkpoint writing")
+}
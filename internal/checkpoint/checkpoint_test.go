@@ -0,0 +1,123 @@
+package checkpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpen(t *testing.T) {
+	s, err := Open(t.TempDir(), false)
+	assert.Nil(t, err)
+	assert.NotNil(t, s)
+	assert.Nil(t, s.Close())
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, false)
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Push(Task{URL: "http://go.test/", Depth: 0, WithPanic: false, Seed: "http://go.test/", Referrer: "", Seq: 0}))
+	assert.Nil(t, s.Push(Task{URL: "http://go.test/b", Depth: 1, WithPanic: false, Seed: "http://go.test/", Referrer: "", Seq: 0}))
+	assert.Nil(t, s.Push(Task{URL: "http://go.test/a", Depth: 1, WithPanic: false, Seed: "http://go.test/", Referrer: "", Seq: 0}))
+	assert.Nil(t, s.Visit("http://other.test/"))
+	assert.Nil(t, s.Emit("http://go.test/", []byte(`{"URL":"http://go.test/"}`)))
+	assert.Nil(t, s.Emit("http://other.test/", nil))
+	assert.Nil(t, s.Done("http://go.test/"))
+	assert.Nil(t, s.Close())
+
+	s, err = Open(dir, true)
+	assert.Nil(t, err)
+
+	visited, pending, err := s.Restore()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"http://go.test/", "http://go.test/a", "http://go.test/b", "http://other.test/"}, visited)
+	assert.Equal(t, 2, len(pending))
+	assert.Equal(t, "http://go.test/b", pending[0].URL)
+	assert.Equal(t, "http://go.test/a", pending[1].URL)
+	assert.Equal(t, uint64(1), pending[0].Depth)
+	assert.Equal(t, "http://go.test/", pending[0].Seed)
+
+	emitted, err := s.Emitted("http://go.test/")
+	assert.Nil(t, err)
+	assert.True(t, emitted)
+
+	emitted, err = s.Emitted("http://go.test/a")
+	assert.Nil(t, err)
+	assert.False(t, emitted)
+
+	results, err := s.Results()
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"URL":"http://go.test/"}`)}, results, "only the kept results are returned")
+
+	assert.Nil(t, s.Close())
+}
+
+func TestOpenWithoutResume(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, false)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Visit("http://go.test/"))
+	assert.Nil(t, s.Close())
+
+	s, err = Open(dir, false)
+	assert.Nil(t, err)
+
+	visited, pending, err := s.Restore()
+	assert.Nil(t, err)
+	assert.Empty(t, visited)
+	assert.Empty(t, pending)
+	assert.Nil(t, s.Close())
+}
+
+func TestSaveConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := LoadConfig(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, cfg, "there is no checkpoint")
+
+	s, err := Open(dir, false)
+	assert.Nil(t, err)
+	assert.Nil(t, s.SaveConfig([]byte("url: http://go.test/\n")))
+	assert.Nil(t, s.Close())
+
+	cfg, err = LoadConfig(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "url: http://go.test/\n", string(cfg))
+
+	s, err = Open(dir, false)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Close())
+
+	cfg, err = LoadConfig(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, cfg, "the configuration is dropped with the previous state")
+}
+
+func TestBufferedWrites(t *testing.T) {
+	s, err := Open(t.TempDir(), false)
+	assert.Nil(t, err)
+
+	defer s.Close()
+
+	db := s.(*store).db
+	writes := db.Stats().TxStats.Write
+
+	for _, u := range []string{"http://go.test/a", "http://go.test/b", "http://go.test/c"} {
+		assert.Nil(t, s.Push(Task{URL: u, Depth: 1, WithPanic: false, Seed: "http://go.test/", Referrer: "", Seq: 0}))
+	}
+
+	assert.Nil(t, s.Visit("http://other.test/"))
+	assert.Equal(t, writes, db.Stats().TxStats.Write, "pushes are buffered")
+
+	assert.Nil(t, s.Done("http://go.test/"))
+
+	_, pending, err := s.Restore()
+	assert.Nil(t, err)
+	assert.Len(t, pending, 3)
+	assert.Equal(t, []uint64{1, 2, 3}, []uint64{pending[0].Seq, pending[1].Seq, pending[2].Seq})
+}
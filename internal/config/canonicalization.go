@@ -0,0 +1,37 @@
+package config
+
+// DefaultTrackingParams - query parameters removed from urls unless KeepTrackingParams is set,
+// a trailing "*" matches any suffix
+var DefaultTrackingParams = []string{ //nolint:gochecknoglobals
+	"utm_*", "gclid", "dclid", "fbclid", "yclid", "msclkid", "mc_cid", "mc_eid", "_openstat",
+}
+
+// Canonicalization - rules of url normalization applied before the visited check and output
+type Canonicalization struct {
+	// KeepTrackingParams - does not remove tracking query parameters
+	KeepTrackingParams bool `yaml:"keep_tracking_params"`
+	// TrackingParams - query parameters removed from urls, DefaultTrackingParams when empty
+	TrackingParams []string `yaml:"tracking_params"`
+	// FoldTrailingSlash - removes the trailing slash of a non-root path
+	FoldTrailingSlash bool `yaml:"fold_trailing_slash"`
+	// FoldIndex - removes index.html and index.htm at the end of a path
+	FoldIndex bool `yaml:"fold_index"`
+}
+
+func (c *Canonicalization) merge(o Canonicalization) {
+	if o.KeepTrackingParams {
+		c.KeepTrackingParams = o.KeepTrackingParams
+	}
+
+	if len(o.TrackingParams) > 0 {
+		c.TrackingParams = o.TrackingParams
+	}
+
+	if o.FoldTrailingSlash {
+		c.FoldTrailingSlash = o.FoldTrailingSlash
+	}
+
+	if o.FoldIndex {
+		c.FoldIndex = o.FoldIndex
+	}
+}
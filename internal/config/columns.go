@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+// Output columns of a crawl result
+const (
+	ColumnURL           = "url"
+	ColumnTitle         = "title"
+	ColumnSkipped       = "skipped"
+	ColumnStatus        = "status"
+	ColumnError         = "error"
+	ColumnFinalURL      = "final_url"
+	ColumnRedirects     = "redirects"
+	ColumnContentType   = "content_type"
+	ColumnContentLength = "content_length"
+	ColumnTruncated     = "truncated"
+	ColumnCharset       = "charset"
+	ColumnCanonical     = "canonical"
+	ColumnIndexability  = "indexability"
+	ColumnLastModified  = "last_modified"
+	ColumnResponseTime  = "response_time"
+	ColumnRetries       = "retries"
+	ColumnDepth         = "depth"
+	ColumnReferrer      = "referrer"
+	ColumnKind          = "kind"
+	ColumnSource        = "source"
+	ColumnCertIssuer    = "cert_issuer"
+	ColumnCertExpires   = "cert_expires"
+)
+
+// AvailableColumns - returns all supported output columns in the default order
+func AvailableColumns() []string {
+	return []string{
+		ColumnURL,
+		ColumnTitle,
+		ColumnSkipped,
+		ColumnStatus,
+		ColumnError,
+		ColumnFinalURL,
+		ColumnRedirects,
+		ColumnContentType,
+		ColumnContentLength,
+		ColumnTruncated,
+		ColumnCharset,
+		ColumnCanonical,
+		ColumnIndexability,
+		ColumnLastModified,
+		ColumnResponseTime,
+		ColumnRetries,
+		ColumnDepth,
+		ColumnReferrer,
+		ColumnKind,
+		ColumnSource,
+		ColumnCertIssuer,
+		ColumnCertExpires,
+	}
+}
+
+// DefaultColumns - returns the columns output when none are configured
+func DefaultColumns() []string {
+	return []string{ColumnURL, ColumnTitle, ColumnSkipped}
+}
+
+// parseColumns - splits a comma separated list of columns, "all" stands for every available column
+func parseColumns(value string) (columns []string) {
+	for _, column := range strings.Split(value, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+
+		if column == "all" {
+			columns = append(columns, AvailableColumns()...)
+
+			continue
+		}
+
+		if column != "" {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns
+}
+
+func validateColumns(columns []string) error {
+	available := make(map[string]struct{})
+
+	for _, column := range AvailableColumns() {
+		available[column] = struct{}{}
+	}
+
+	for _, column := range columns {
+		if _, ok := available[column]; !ok {
+			return errors.New("unknown output column (" + column + "), available: " + strings.Join(AvailableColumns(), ", "))
+		}
+	}
+
+	return nil
+}
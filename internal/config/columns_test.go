@@ -0,0 +1,19 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseColumns(t *testing.T) {
+	assert.Nil(t, parseColumns(""))
+	assert.Equal(t, []string{ColumnURL, ColumnStatus, ColumnReferrer}, parseColumns("url, Status,,referrer"))
+	assert.Equal(t, AvailableColumns(), parseColumns("all"))
+}
+
+func Test_validateColumns(t *testing.T) {
+	assert.Nil(t, validateColumns(AvailableColumns()))
+	assert.Nil(t, validateColumns(nil))
+	assert.Error(t, validateColumns([]string{ColumnURL, "size"}))
+}
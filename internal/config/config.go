@@ -10,12 +10,14 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
 
 	"github.com/joho/godotenv"
+	"github.com/vfunin/crawler/internal/checkpoint"
 )
 
 type key int
@@ -38,6 +40,9 @@ const (
 	DefaultWithPanic      = false
 	DefaultLogLevel       = "error"
 	DefaultConfigFilePath = ""
+	DefaultWorkers        = 10
+	DefaultUserAgent      = "crawler/1.0 (+https://github.com/vfunin/crawler)"
+	DefaultIgnoreRobots   = false
 )
 
 type Configuration interface {
@@ -46,23 +51,73 @@ type Configuration interface {
 	ShowHelp()
 	NeedHelp() bool
 	URL() string
+	Seeds() []Seed
 	MaxDepth() uint64
 	Timeout() int
 	DepthIncStep() int
 	Output() string
+	Format() string
+	Delimiter() rune
+	Columns() []string
 	WithPanic() bool
+	Workers() int
+	Politeness() Politeness
+	Transport() Transport
+	Retry() Retry
+	UserAgent() string
+	Request() Request
+	Login() Login
+	Proxy() Proxy
+	TLS() TLS
+	Content() Content
+	Links() Links
+	IgnoreRobots() bool
+	Directives() Directives
+	Sitemaps() Sitemaps
+	SitemapOutput() SitemapOutput
+	Scope() Scope
+	Canonicalization() Canonicalization
+	LinkCheck() LinkCheck
+	CheckpointDir() string
+	Resume() bool
+	Snapshot() ([]byte, error)
 }
 
 type configuration struct {
 	needHelp     bool
-	url          string        `yaml:"url"`
-	maxDepth     uint64        `yaml:"max_depth"`
-	timeout      int           `yaml:"timeout"`
-	depthIncStep int           `yaml:"depth_inc_step"`
-	output       string        `yaml:"output"`
-	jsonLog      bool          `yaml:"json_log"`
-	withPanic    bool          `yaml:"with_panic"`
-	logLevel     zerolog.Level `yaml:"log_level"`
+	url          string           `yaml:"url"`
+	seeds        []Seed           `yaml:"seeds"`
+	seedsFile    string           `yaml:"seeds_file"`
+	maxDepth     uint64           `yaml:"max_depth"`
+	timeout      int              `yaml:"timeout"`
+	depthIncStep int              `yaml:"depth_inc_step"`
+	output       string           `yaml:"output"`
+	format       string           `yaml:"format"`
+	delimiter    string           `yaml:"delimiter"`
+	columns      []string         `yaml:"columns"`
+	jsonLog      bool             `yaml:"json_log"`
+	withPanic    bool             `yaml:"with_panic"`
+	logLevel     zerolog.Level    `yaml:"log_level"`
+	workers      int              `yaml:"workers"`
+	politeness   Politeness       `yaml:"politeness"`
+	transport    Transport        `yaml:"transport"`
+	retry        Retry            `yaml:"retry"`
+	userAgent    string           `yaml:"user_agent"`
+	request      Request          `yaml:"request"`
+	login        Login            `yaml:"login"`
+	proxy        Proxy            `yaml:"proxy"`
+	tls          TLS              `yaml:"tls"`
+	content      Content          `yaml:"content"`
+	links        Links            `yaml:"links"`
+	ignoreRobots bool             `yaml:"ignore_robots"`
+	directives   Directives       `yaml:"directives"`
+	sitemaps     Sitemaps         `yaml:"sitemaps"`
+	sitemapOut   SitemapOutput    `yaml:"sitemap_output"`
+	scope        Scope            `yaml:"scope"`
+	canonical    Canonicalization `yaml:"canonicalization"`
+	linkCheck    LinkCheck        `yaml:"check_links"`
+	checkpoint   string           `yaml:"checkpoint"`
+	resume       bool
 }
 
 func (c *configuration) NeedHelp() bool {
@@ -73,6 +128,39 @@ func (c *configuration) URL() string {
 	return c.url
 }
 
+// Seeds - returns the url followed by the other seeds, a seed listed again is skipped
+func (c *configuration) Seeds() []Seed {
+	seeds := make([]Seed, 0, len(c.seeds)+1)
+	seen := make(map[string]struct{}, len(c.seeds)+1)
+
+	if c.url != "" {
+		first := Seed{URL: c.url, MaxDepth: nil, Scope: nil}
+
+		// the url may be listed among the seeds with its own settings
+		for _, seed := range c.seeds {
+			if seed.URL == c.url {
+				first = seed
+
+				break
+			}
+		}
+
+		seeds = append(seeds, first)
+		seen[c.url] = struct{}{}
+	}
+
+	for _, seed := range c.seeds {
+		if _, ok := seen[seed.URL]; ok {
+			continue
+		}
+
+		seen[seed.URL] = struct{}{}
+		seeds = append(seeds, seed)
+	}
+
+	return seeds
+}
+
 func (c *configuration) MaxDepth() uint64 {
 	return c.maxDepth
 }
@@ -89,6 +177,11 @@ func (c *configuration) Output() string {
 	return c.output
 }
 
+// Columns - returns the result columns in the output order
+func (c *configuration) Columns() []string {
+	return c.columns
+}
+
 func (c *configuration) JSONLog() bool {
 	return c.jsonLog
 }
@@ -101,6 +194,97 @@ func (c *configuration) LogLevel() zerolog.Level {
 	return c.logLevel
 }
 
+func (c *configuration) Workers() int {
+	return c.workers
+}
+
+func (c *configuration) Politeness() Politeness {
+	return c.politeness
+}
+
+func (c *configuration) Transport() Transport {
+	return c.transport
+}
+
+func (c *configuration) Retry() Retry {
+	return c.retry
+}
+
+func (c *configuration) UserAgent() string {
+	return c.userAgent
+}
+
+func (c *configuration) Request() Request {
+	return c.request
+}
+
+func (c *configuration) Login() Login {
+	return c.login
+}
+
+func (c *configuration) Proxy() Proxy {
+	return c.proxy
+}
+
+func (c *configuration) TLS() TLS {
+	return c.tls
+}
+
+func (c *configuration) Content() Content {
+	return c.content
+}
+
+func (c *configuration) Links() Links {
+	return c.links
+}
+
+func (c *configuration) IgnoreRobots() bool {
+	return c.ignoreRobots
+}
+
+func (c *configuration) Directives() Directives {
+	return c.directives
+}
+
+func (c *configuration) Sitemaps() Sitemaps {
+	return c.sitemaps
+}
+
+func (c *configuration) SitemapOutput() SitemapOutput {
+	return c.sitemapOut
+}
+
+func (c *configuration) Scope() Scope {
+	return c.scope
+}
+
+func (c *configuration) Canonicalization() Canonicalization {
+	return c.canonical
+}
+
+func (c *configuration) LinkCheck() LinkCheck {
+	return c.linkCheck
+}
+
+func (c *configuration) CheckpointDir() string {
+	return c.checkpoint
+}
+
+func (c *configuration) Resume() bool {
+	return c.resume
+}
+
+// Snapshot - returns the configuration as yaml to be saved with the crawl progress, header values, credentials
+// and login fields are saved only as environment variable references
+func (c *configuration) Snapshot() ([]byte, error) {
+	fc := c.fileConfiguration()
+	fc.Request = c.request.snapshot()
+	fc.Login = c.login.snapshot()
+	fc.Proxy = c.proxy.snapshot()
+
+	return yaml.Marshal(fc)
+}
+
 func (c *configuration) fillFromEnv() (err error) {
 	if value := os.Getenv("URL"); value != "" {
 		c.url = value
@@ -110,6 +294,10 @@ func (c *configuration) fillFromEnv() (err error) {
 		c.output = value
 	}
 
+	if value := os.Getenv("USER_AGENT"); value != "" {
+		c.userAgent = value
+	}
+
 	var v int
 
 	if value := os.Getenv("MAX_DEPTH"); value != "" {
@@ -136,6 +324,14 @@ func (c *configuration) fillFromEnv() (err error) {
 		c.timeout = v
 	}
 
+	if value := os.Getenv("WORKERS"); value != "" {
+		if v, err = strconv.Atoi(value); err != nil {
+			return
+		}
+
+		c.workers = v
+	}
+
 	if value := os.Getenv("LOG_LEVEL"); value != "" {
 		if c.logLevel, err = zerolog.ParseLevel(value); err != nil {
 			return err
@@ -146,13 +342,86 @@ func (c *configuration) fillFromEnv() (err error) {
 }
 
 func (c *configuration) validate() (err error) {
-	if _, err = url.ParseRequestURI(c.url); err != nil {
-		return errors.New("wrong url (" + c.url + "); error: " + err.Error())
+	if c.resume && c.url == "" && len(c.seeds) == 0 {
+		return c.validateSettings()
+	}
+
+	if c.url != "" || len(c.seeds) == 0 {
+		if _, err = url.ParseRequestURI(c.url); err != nil {
+			return errors.New("wrong url (" + c.url + "); error: " + err.Error())
+		}
+	}
+
+	for _, seed := range c.seeds {
+		if err = seed.validate(); err != nil {
+			return err
+		}
+	}
+
+	return c.validateSettings()
+}
+
+func (c *configuration) validateSettings() (err error) {
+	if c.workers < 0 {
+		return errors.New("wrong workers count (" + strconv.Itoa(c.workers) + ")")
+	}
+
+	if err = validateColumns(c.columns); err != nil {
+		return err
+	}
+
+	if err = c.validateFormat(); err != nil {
+		return err
+	}
+
+	if err = c.scope.validate(); err != nil {
+		return err
+	}
+
+	if err = c.login.validate(); err != nil {
+		return err
+	}
+
+	if err = c.proxy.validate(); err != nil {
+		return err
+	}
+
+	if err = c.tls.validate(); err != nil {
+		return err
+	}
+
+	if err = c.links.validate(); err != nil {
+		return err
+	}
+
+	if err = c.sitemaps.validate(); err != nil {
+		return err
+	}
+
+	if err = c.sitemapOut.validate(); err != nil {
+		return err
 	}
 
 	return
 }
 
+// validateFormat - json arrays and xml documents can not be appended to, so resumed crawls are output in other formats
+func (c *configuration) validateFormat() error {
+	if err := validateFormat(c.Format()); err != nil {
+		return err
+	}
+
+	if err := validateDelimiter(c.delimiter); err != nil {
+		return err
+	}
+
+	if c.resume && c.OutputToFile() && (c.Format() == FormatJSON || c.Format() == FormatXML) {
+		return errors.New("resumed crawl can not append to " + c.Format() + " output, use csv, tsv or jsonl")
+	}
+
+	return nil
+}
+
 func (c *configuration) loadFromEnv() (err error) {
 	if err = fillEnv(); err != nil {
 		return
@@ -165,8 +434,38 @@ func (c *configuration) loadFromEnv() (err error) {
 	return
 }
 
+// loadFromCheckpoint - replaces the values with the configuration saved with the crawl progress, reports whether
+// the checkpoint has it
+func (c *configuration) loadFromCheckpoint(dir string) (ok bool, err error) {
+	var (
+		content []byte
+		fc      fileConfiguration
+		saved   configuration
+	)
+
+	if content, err = checkpoint.LoadConfig(dir); err != nil || content == nil {
+		return false, err
+	}
+
+	if err = yaml.Unmarshal(content, &fc); err != nil {
+		return false, err
+	}
+
+	if saved, err = fc.configuration(); err != nil {
+		return false, err
+	}
+
+	*c = saved
+
+	return true, nil
+}
+
 func (c *configuration) loadFromFile(path string) (err error) {
-	var content []byte
+	var (
+		content  []byte
+		fc       fileConfiguration
+		fromFile configuration
+	)
 
 	if filepath.Ext(path) != ".yaml" {
 		return
@@ -176,20 +475,78 @@ func (c *configuration) loadFromFile(path string) (err error) {
 		return
 	}
 
-	err = yaml.Unmarshal(content, &c)
+	if err = yaml.Unmarshal(content, &fc); err != nil {
+		return
+	}
+
+	if fromFile, err = fc.configuration(); err != nil {
+		return
+	}
+
+	c.merge(fromFile)
 
 	return
 }
 
-func (c *configuration) loadFromFlags(fc configuration) {
+// loadFromFlags - overrides the values with the flags, flags with non-zero defaults are applied only when they are
+// given, so their defaults do not override the environment and the file while given zero values are applied
+func (c *configuration) loadFromFlags(fc configuration, given map[string]bool) {
+	flags := fc
+	fc.maxDepth, fc.timeout, fc.depthIncStep, fc.workers, fc.logLevel = 0, 0, 0, 0, 0
+
+	c.merge(fc)
+
+	if given["m"] {
+		c.maxDepth = flags.maxDepth
+	}
+
+	if given["t"] {
+		c.timeout = flags.timeout
+	}
+
+	if given["s"] {
+		c.depthIncStep = flags.depthIncStep
+	}
+
+	if given["w"] {
+		c.workers = flags.workers
+	}
+
+	if given["l"] {
+		c.logLevel = flags.logLevel
+	}
+}
+
+// merge - overrides the current values with non-zero values of fc
+func (c *configuration) merge(fc configuration) {
 	if fc.url != "" {
 		c.url = fc.url
 	}
 
+	if len(fc.seeds) > 0 {
+		c.seeds = fc.seeds
+	}
+
+	if fc.seedsFile != "" {
+		c.seedsFile = fc.seedsFile
+	}
+
 	if fc.output != "" {
 		c.output = fc.output
 	}
 
+	if fc.format != "" {
+		c.format = fc.format
+	}
+
+	if fc.delimiter != "" {
+		c.delimiter = fc.delimiter
+	}
+
+	if len(fc.columns) > 0 {
+		c.columns = fc.columns
+	}
+
 	if fc.maxDepth != 0 {
 		c.maxDepth = fc.maxDepth
 	}
@@ -217,6 +574,45 @@ func (c *configuration) loadFromFlags(fc configuration) {
 	if fc.logLevel != 0 {
 		c.logLevel = fc.logLevel
 	}
+
+	if fc.workers != 0 {
+		c.workers = fc.workers
+	}
+
+	c.politeness.merge(fc.politeness)
+	c.transport.merge(fc.transport)
+	c.retry.merge(fc.retry)
+
+	if fc.userAgent != "" {
+		c.userAgent = fc.userAgent
+	}
+
+	c.request.merge(fc.request)
+	c.login.merge(fc.login)
+	c.proxy.merge(fc.proxy)
+	c.tls.merge(fc.tls)
+	c.content.merge(fc.content)
+	c.links.merge(fc.links)
+
+	if fc.ignoreRobots {
+		c.ignoreRobots = fc.ignoreRobots
+	}
+
+	c.directives.merge(fc.directives)
+	c.sitemaps.merge(fc.sitemaps)
+	c.sitemapOut.merge(fc.sitemapOut)
+
+	c.scope.merge(fc.scope)
+	c.canonical.merge(fc.canonical)
+	c.linkCheck.merge(fc.linkCheck)
+
+	if fc.checkpoint != "" {
+		c.checkpoint = fc.checkpoint
+	}
+
+	if fc.resume {
+		c.resume = fc.resume
+	}
 }
 
 func (c *configuration) configureBaseLogger() {
@@ -248,26 +644,68 @@ func (c *configuration) configureBaseLogger() {
 
 // New Reads configuration from files (yaml, json), .env, environment variables or arguments and returns.
 func New() (Configuration, error) {
-	fConf, cfgPath, err := readFlags()
+	fConf, cfgPath, given, err := readFlags(flag.CommandLine, os.Args[1:])
 	if err != nil {
 		return nil, err
 	}
 
-	c := &configuration{} //nolint:exhaustivestruct
+	return load(fConf, cfgPath, given)
+}
+
+// load - applies the environment, the file at cfgPath and the given flags to the defaults in this order
+func load(fConf configuration, cfgPath string, given map[string]bool) (Configuration, error) {
+	logLevel, err := zerolog.ParseLevel(DefaultLogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &configuration{ //nolint:exhaustivestruct
+		maxDepth:     DefaultMaxDepth,
+		timeout:      DefaultTimeout,
+		depthIncStep: DefaultDepthStep,
+		logLevel:     logLevel,
+		workers:      DefaultWorkers,
+		columns:      DefaultColumns(),
+		politeness:   DefaultPoliteness(),
+		transport:    DefaultTransport(),
+		retry:        DefaultRetry(),
+		content:      DefaultContent(),
+		links:        DefaultLinks(),
+		userAgent:    DefaultUserAgent,
+	}
 
-	if err = c.loadFromEnv(); err != nil {
-		if !errors.Is(err, os.ErrNotExist) {
+	// a resumed crawl continues with the configuration it was started with, only the given flags change it
+	restored := false
+	if fConf.resume {
+		if restored, err = c.loadFromCheckpoint(fConf.checkpoint); err != nil {
 			return nil, err
 		}
 	}
 
-	if err = c.loadFromFile(cfgPath); err != nil {
-		return nil, err
+	if !restored {
+		if err = c.loadFromEnv(); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+
+		if err = c.loadFromFile(cfgPath); err != nil {
+			return nil, err
+		}
 	}
 
-	c.loadFromFlags(fConf)
+	c.loadFromFlags(fConf, given)
 	c.configureBaseLogger()
 
+	if err = c.loadSeedsFile(); err != nil {
+		return c, err
+	}
+
+	// the link checker stays on the seed host unless the scope says otherwise, external links are only checked
+	if c.linkCheck.Enabled && !c.scope.RestrictsHosts() {
+		c.scope.SameHost = true
+	}
+
 	if err = c.validate(); err != nil {
 		return c, err
 	}
@@ -279,6 +717,30 @@ func (c *configuration) OutputToFile() bool {
 	return c.output != ""
 }
 
+// Format - returns the configured output format, the one of the output file extension or csv
+func (c *configuration) Format() string {
+	if c.format != "" {
+		return strings.ToLower(c.format)
+	}
+
+	if format := formatOf(c.output); format != "" {
+		return format
+	}
+
+	return FormatCSV
+}
+
+// Delimiter - returns the separator of the csv values
+func (c *configuration) Delimiter() rune {
+	if c.delimiter == "" {
+		return DefaultDelimiter
+	}
+
+	r, _ := utf8.DecodeRuneInString(c.delimiter)
+
+	return r
+}
+
 func (c configuration) String() (result string) {
 	return fmt.Sprintf("%#v", c)
 }
@@ -292,24 +754,137 @@ Crawler is a program for find titles in URLs.
 Examples of using:
 ./bin/crawler -u https://ya.ru # Finds all links and title up to the second level of nesting and outputs to the console
 ./bin/crawler -u https://ya.ru -o result.csv # Same but output to file
+./bin/crawler -u https://ya.ru -o result.jsonl # Outputs json lines, the format is detected from the file extension
+./bin/crawler -u https://ya.ru --format csv --delimiter , # Outputs comma separated values to the console
+./bin/crawler -u https://ya.ru -u https://go.dev --seeds-file urls.txt # Starts from both urls and every url of the file
+cat urls.txt | ./bin/crawler --seeds-file - # Starts from the urls read from stdin
+./bin/crawler -u https://ya.ru --columns url,status,response_time,referrer # Outputs the status, the response time and the referring page of every url
 ./bin/crawler -u https://ya.ru -l # Use for change log level (string debug/info/error etc)
-./bin/crawler -u https://ya.ru -p # Fires panic and recover in first link`)
-}
-
-func readFlags() (c configuration, path string, err error) {
-	var ll string
-
-	flag.StringVar(&c.url, "u", DefaultURL, "URL for parsing")
-	flag.Uint64Var(&c.maxDepth, "m", DefaultMaxDepth, "Max depth")
-	flag.IntVar(&c.timeout, "t", DefaultTimeout, "Timeout for request")
-	flag.IntVar(&c.depthIncStep, "s", DefaultDepthStep, "Depth step")
-	flag.StringVar(&c.output, "o", DefaultOutput, "Output - file path or empty for log in console")
-	flag.BoolVar(&c.needHelp, "h", DefaultNeedHelp, "Print help")
-	flag.BoolVar(&c.jsonLog, "j", DefaultJSONLog, "JSON log format")
-	flag.BoolVar(&c.withPanic, "p", DefaultWithPanic, "Show test panic")
-	flag.StringVar(&ll, "l", DefaultLogLevel, "Log level")
-	flag.StringVar(&path, "c", DefaultConfigFilePath, "Config file path")
-	flag.Parse()
+./bin/crawler -u https://ya.ru -p # Fires panic and recover in first link
+./bin/crawler -u https://ya.ru -w 4 # Limits the crawl to 4 concurrent workers
+./bin/crawler -u https://ya.ru -r 0.5 # Sends at most one request every two seconds to each host
+./bin/crawler -u https://ya.ru --no-http2 --dial-timeout 2s --header-timeout 5s # Tunes the connections shared by all requests
+./bin/crawler -u https://ya.ru --retries 5 # Repeats requests failed with a timeout, 429 or 5xx up to 5 times
+./bin/crawler -u https://staging.local -H "X-Env: staging" --cookies # Sends the header with every request and keeps cookies
+./bin/crawler -u https://ya.ru --proxy http://proxy1:3128,http://proxy2:3128 --proxy-round-robin # Sends the requests through the proxies in turn
+./bin/crawler -u https://intranet.local --ca-files corp-ca.pem --cert-expiry 30 # Trusts the private CA and reports certificates expiring within 30 days
+./bin/crawler -u https://ya.ru --max-body-size 1048576 --head-binaries # Reads at most 1 MiB of a page and checks links to binary files with HEAD
+./bin/crawler -u https://ya.ru --check-links --report asset # Also checks images, scripts and stylesheets of the crawled pages
+./bin/crawler -u https://intranet.local --ignore-robots # Crawls urls disallowed by robots.txt
+./bin/crawler -u https://ya.ru --ignore-nofollow --ignore-canonical # Follows nofollow links and links of canonicalized pages
+./bin/crawler -u https://ya.ru --sitemaps --sitemap /feed.xml --columns url,source # Also crawls the urls of the sitemaps and the feed
+./bin/crawler -u https://ya.ru --sitemap-output sitemap.xml --sitemap-gzip # Writes the indexable pages to sitemap.xml.gz
+./bin/crawler -u https://ya.ru --same-host # Follows only links to the seed host
+./bin/crawler -u https://ya.ru --check-links --check-external # Reports broken links grouped by the pages linking to them
+./bin/crawler -u https://ya.ru --checkpoint state # Saves the crawl progress to the state directory
+./bin/crawler --resume state # Continues the interrupted crawl saved to the state directory`)
+}
+
+// readFlags - parses args with fs, given holds the names of the flags which are set by args
+func readFlags(fs *flag.FlagSet, args []string) (c configuration, path string, given map[string]bool, err error) {
+	var ll, resume, columns, proxies, caFiles, follow, report, sitemaps string
+
+	var urls urlFlag
+
+	fs.Var(&urls, "u", "URL for parsing, may be repeated to start from several urls")
+	fs.StringVar(&c.seedsFile, "seeds-file", "", "File with a seed url per line, - reads them from stdin")
+	fs.Uint64Var(&c.maxDepth, "m", DefaultMaxDepth, "Max depth")
+	fs.IntVar(&c.timeout, "t", DefaultTimeout, "Timeout for request")
+	fs.IntVar(&c.depthIncStep, "s", DefaultDepthStep, "Depth step")
+	fs.StringVar(&c.output, "o", DefaultOutput, "Output - file path or empty for log in console")
+	fs.StringVar(&c.format, "format", "", "Output format ("+strings.Join(AvailableFormats(), ", ")+"), detected from the output file extension by default")
+	fs.StringVar(&c.delimiter, "delimiter", "", "Separator of the csv values, ; by default")
+	fs.StringVar(&columns, "columns", "", "Comma separated output columns ("+strings.Join(AvailableColumns(), ", ")+" or all)")
+	fs.BoolVar(&c.needHelp, "h", DefaultNeedHelp, "Print help")
+	fs.BoolVar(&c.jsonLog, "j", DefaultJSONLog, "JSON log format")
+	fs.BoolVar(&c.withPanic, "p", DefaultWithPanic, "Show test panic")
+	fs.StringVar(&ll, "l", DefaultLogLevel, "Log level")
+	fs.StringVar(&path, "c", DefaultConfigFilePath, "Config file path")
+	fs.IntVar(&c.workers, "w", DefaultWorkers, "Number of concurrent crawl workers")
+	fs.Float64Var(&c.politeness.Rate, "r", 0, "Requests per second to a single host (negative for unlimited)")
+	fs.IntVar(&c.transport.MaxIdleConnsPerHost, "max-idle-per-host", 0, "Idle keep-alive connections kept open to a single host")
+	fs.DurationVar(&c.transport.IdleConnTimeout, "idle-timeout", 0, "Time an idle keep-alive connection is kept open")
+	fs.BoolVar(&c.transport.DisableHTTP2, "no-http2", false, "Use HTTP/1.1 only")
+	fs.DurationVar(&c.transport.DialTimeout, "dial-timeout", 0, "Timeout of establishing a connection")
+	fs.DurationVar(&c.transport.TLSHandshakeTimeout, "tls-timeout", 0, "Timeout of the tls handshake")
+	fs.DurationVar(&c.transport.ResponseHeaderTimeout, "header-timeout", 0, "Timeout of waiting for the response headers")
+	fs.IntVar(&c.retry.MaxRetries, "retries", 0, "Retries of requests failed with a transient error (negative to disable)")
+	headers := make(headerFlag)
+	c.request.Headers = headers
+
+	fs.StringVar(&c.userAgent, "user-agent", "", "User agent sent with requests and used for robots.txt rules")
+	fs.Var(headers, "H", "Header sent with every request as \"Name: value\", may be repeated")
+	fs.BoolVar(&c.request.Cookies, "cookies", false, "Keep cookies set by the servers for the next requests")
+	fs.StringVar(&proxies, "proxy", "", "Comma separated http, https or socks5 proxy urls used instead of HTTP_PROXY and HTTPS_PROXY")
+	fs.BoolVar(&c.proxy.RoundRobin, "proxy-round-robin", false, "Rotate through the proxies with every request")
+	fs.StringVar(&caFiles, "ca-files", "", "Comma separated pem files of certificate authorities trusted in addition to the system ones")
+	fs.BoolVar(&c.tls.InsecureSkipVerify, "insecure", false, "Accept any server certificate")
+	fs.IntVar(&c.tls.ExpiryDays, "cert-expiry", 0, "Report server certificates expiring within the days")
+	fs.Int64Var(&c.content.MaxBodySize, "max-body-size", 0, "Bytes read from a response body (negative for no limit)")
+	fs.BoolVar(&c.content.HeadBinaries, "head-binaries", false, "Check urls of binary files with HEAD instead of downloading them")
+	fs.StringVar(&follow, "follow", "", "Comma separated kinds of links which are crawled (navigation, asset, alternate, canonical)")
+	fs.StringVar(&report, "report", "", "Comma separated kinds of links which are output without being crawled")
+	fs.BoolVar(&c.ignoreRobots, "ignore-robots", DefaultIgnoreRobots, "Ignore robots.txt rules")
+	fs.BoolVar(&c.directives.IgnoreNoFollow, "ignore-nofollow", false, "Follow nofollow links and links of nofollow pages")
+	fs.BoolVar(&c.directives.IgnoreCanonical, "ignore-canonical", false, "Follow links of pages canonicalized to another page")
+	fs.BoolVar(&c.sitemaps.Discover, "sitemaps", false, "Seed the crawl with the sitemaps of robots.txt and /sitemap.xml")
+	fs.StringVar(&sitemaps, "sitemap", "", "Comma separated sitemaps or rss/atom feeds seeding the crawl")
+	fs.StringVar(&c.sitemapOut.Path, "sitemap-output", "", "File to write the sitemap of the indexable pages to")
+	fs.BoolVar(&c.sitemapOut.Gzip, "sitemap-gzip", false, "Compress the generated sitemaps")
+	fs.StringVar(&c.sitemapOut.BaseURL, "sitemap-base-url", "", "Url the generated sitemaps are published at")
+	fs.BoolVar(&c.scope.SameHost, "same-host", false, "Follow only links to the seed host")
+	fs.BoolVar(&c.scope.AllowSubdomains, "subdomains", false, "Follow links to subdomains of the seed host")
+	fs.BoolVar(&c.scope.ReportOutOfScope, "report-out-of-scope", false, "Output links which are not followed")
+	fs.BoolVar(&c.linkCheck.Enabled, "check-links", false, "Report broken links and exit with a non-zero code when any are found")
+	fs.BoolVar(&c.linkCheck.External, "check-external", false, "Check links out of the crawl scope")
+	fs.BoolVar(&c.linkCheck.HeadExternal, "head-external", false, "Check links out of the crawl scope with HEAD requests")
+	fs.StringVar(&c.checkpoint, "checkpoint", "", "Directory to save the crawl progress to")
+	fs.StringVar(&resume, "resume", "", "Directory to resume the crawl from")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	given = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	c.columns = parseColumns(columns)
+
+	if proxies != "" {
+		c.proxy.Proxies = strings.Split(proxies, ",")
+	}
+
+	if caFiles != "" {
+		c.tls.CAFiles = strings.Split(caFiles, ",")
+	}
+
+	if follow != "" {
+		c.links.Follow = strings.Split(follow, ",")
+	}
+
+	if report != "" {
+		c.links.Report = strings.Split(report, ",")
+	}
+
+	if sitemaps != "" {
+		c.sitemaps.URLs = strings.Split(sitemaps, ",")
+	}
+
+	for i, u := range urls {
+		if i == 0 {
+			c.url = u
+
+			continue
+		}
+
+		c.seeds = append(c.seeds, Seed{URL: u, MaxDepth: nil, Scope: nil})
+	}
+
+	if resume != "" {
+		c.checkpoint = resume
+		c.resume = true
+	}
 
 	if c.logLevel, err = zerolog.ParseLevel(ll); err != nil {
 		return
@@ -1,14 +1,17 @@
 package config
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/checkpoint"
 )
 
 type fields struct {
@@ -598,8 +601,30 @@ func ExampleConfiguration_ShowHelp() {
 	//Examples of using:
 	//./bin/crawler -u https://ya.ru # Finds all links and title up to the second level of nesting and outputs to the console
 	//./bin/crawler -u https://ya.ru -o result.csv # Same but output to file
+	//./bin/crawler -u https://ya.ru -o result.jsonl # Outputs json lines, the format is detected from the file extension
+	//./bin/crawler -u https://ya.ru --format csv --delimiter , # Outputs comma separated values to the console
+	//./bin/crawler -u https://ya.ru -u https://go.dev --seeds-file urls.txt # Starts from both urls and every url of the file
+	//cat urls.txt | ./bin/crawler --seeds-file - # Starts from the urls read from stdin
+	//./bin/crawler -u https://ya.ru --columns url,status,response_time,referrer # Outputs the status, the response time and the referring page of every url
 	//./bin/crawler -u https://ya.ru -l # Use for change log level (string debug/info/error etc)
 	//./bin/crawler -u https://ya.ru -p # Fires panic and recover in first link
+	//./bin/crawler -u https://ya.ru -w 4 # Limits the crawl to 4 concurrent workers
+	//./bin/crawler -u https://ya.ru -r 0.5 # Sends at most one request every two seconds to each host
+	//./bin/crawler -u https://ya.ru --no-http2 --dial-timeout 2s --header-timeout 5s # Tunes the connections shared by all requests
+	//./bin/crawler -u https://ya.ru --retries 5 # Repeats requests failed with a timeout, 429 or 5xx up to 5 times
+	//./bin/crawler -u https://staging.local -H "X-Env: staging" --cookies # Sends the header with every request and keeps cookies
+	//./bin/crawler -u https://ya.ru --proxy http://proxy1:3128,http://proxy2:3128 --proxy-round-robin # Sends the requests through the proxies in turn
+	//./bin/crawler -u https://intranet.local --ca-files corp-ca.pem --cert-expiry 30 # Trusts the private CA and reports certificates expiring within 30 days
+	//./bin/crawler -u https://ya.ru --max-body-size 1048576 --head-binaries # Reads at most 1 MiB of a page and checks links to binary files with HEAD
+	//./bin/crawler -u https://ya.ru --check-links --report asset # Also checks images, scripts and stylesheets of the crawled pages
+	//./bin/crawler -u https://intranet.local --ignore-robots # Crawls urls disallowed by robots.txt
+	//./bin/crawler -u https://ya.ru --ignore-nofollow --ignore-canonical # Follows nofollow links and links of canonicalized pages
+	//./bin/crawler -u https://ya.ru --sitemaps --sitemap /feed.xml --columns url,source # Also crawls the urls of the sitemaps and the feed
+	//./bin/crawler -u https://ya.ru --sitemap-output sitemap.xml --sitemap-gzip # Writes the indexable pages to sitemap.xml.gz
+	//./bin/crawler -u https://ya.ru --same-host # Follows only links to the seed host
+	//./bin/crawler -u https://ya.ru --check-links --check-external # Reports broken links grouped by the pages linking to them
+	//./bin/crawler -u https://ya.ru --checkpoint state # Saves the crawl progress to the state directory
+	//./bin/crawler --resume state # Continues the interrupted crawl saved to the state directory
 }
 
 func TestString(t *testing.T) {
@@ -622,7 +647,7 @@ func TestString(t *testing.T) {
 				withPanic:    false,
 				logLevel:     0,
 			},
-			wantResult: "config.configuration{needHelp:true, url:\"\", maxDepth:0x0, timeout:0, depthIncStep:0, output:\"\", jsonLog:false, withPanic:false, logLevel:0}",
+			wantResult: "config.configuration{needHelp:true, url:\"\", seeds:[]config.Seed(nil), seedsFile:\"\", maxDepth:0x0, timeout:0, depthIncStep:0, output:\"\", format:\"\", delimiter:\"\", columns:[]string(nil), jsonLog:false, withPanic:false, logLevel:0, workers:0, politeness:config.Politeness{HostPoliteness:config.HostPoliteness{Rate:0, Burst:0, MaxConcurrent:0, Delay:0, RandomDelay:0}, Hosts:map[string]config.HostPoliteness(nil)}, transport:config.Transport{MaxIdleConns:0, MaxIdleConnsPerHost:0, IdleConnTimeout:0, DisableHTTP2:false, DialTimeout:0, TLSHandshakeTimeout:0, ResponseHeaderTimeout:0}, retry:config.Retry{MaxRetries:0, InitialBackoff:0, MaxBackoff:0, BreakerThreshold:0, BreakerCooldown:0}, userAgent:\"\", request:config.Request{Headers:map[string]string(nil), Cookies:false, Hosts:map[string]config.HostRequest(nil)}, login:config.Login{URL:\"\", Form:\"\", Fields:map[string]string(nil), SuccessStatus:0, SuccessURL:\"\", SuccessCookie:\"\"}, proxy:config.Proxy{Proxies:[]string(nil), Rules:[]config.ProxyRule(nil), RoundRobin:false}, tls:config.TLS{CAFiles:[]string(nil), InsecureSkipVerify:false, ClientCerts:map[string]config.ClientCert(nil), ExpiryDays:0}, content:config.Content{MaxBodySize:0, Types:[]string(nil), HeadBinaries:false, BinaryExtensions:[]string(nil)}, links:config.Links{Rules:[]config.LinkRule(nil), Follow:[]string(nil), Report:[]string(nil)}, ignoreRobots:false, directives:config.Directives{IgnoreNoFollow:false, IgnoreCanonical:false}, sitemaps:config.Sitemaps{Discover:false, URLs:[]string(nil)}, sitemapOut:config.SitemapOutput{Path:\"\", Gzip:false, BaseURL:\"\"}, scope:config.Scope{SameHost:false, AllowSubdomains:false, AllowedDomains:[]string(nil), DeniedDomains:[]string(nil), Include:[]string(nil), Exclude:[]string(nil), ReportOutOfScope:false}, canonical:config.Canonicalization{KeepTrackingParams:false, TrackingParams:[]string(nil), FoldTrailingSlash:false, FoldIndex:false}, linkCheck:config.LinkCheck{Enabled:false, External:false, HeadExternal:false}, checkpoint:\"\", resume:false}",
 			wantErr:    false,
 		},
 		{
@@ -638,7 +663,7 @@ func TestString(t *testing.T) {
 				withPanic:    false,
 				logLevel:     0,
 			},
-			wantResult: "config.configuration{needHelp:false, url:\"\", maxDepth:0x0, timeout:0, depthIncStep:99, output:\"test\", jsonLog:true, withPanic:false, logLevel:0}",
+			wantResult: "config.configuration{needHelp:false, url:\"\", seeds:[]config.Seed(nil), seedsFile:\"\", maxDepth:0x0, timeout:0, depthIncStep:99, output:\"test\", format:\"\", delimiter:\"\", columns:[]string(nil), jsonLog:true, withPanic:false, logLevel:0, workers:0, politeness:config.Politeness{HostPoliteness:config.HostPoliteness{Rate:0, Burst:0, MaxConcurrent:0, Delay:0, RandomDelay:0}, Hosts:map[string]config.HostPoliteness(nil)}, transport:config.Transport{MaxIdleConns:0, MaxIdleConnsPerHost:0, IdleConnTimeout:0, DisableHTTP2:false, DialTimeout:0, TLSHandshakeTimeout:0, ResponseHeaderTimeout:0}, retry:config.Retry{MaxRetries:0, InitialBackoff:0, MaxBackoff:0, BreakerThreshold:0, BreakerCooldown:0}, userAgent:\"\", request:config.Request{Headers:map[string]string(nil), Cookies:false, Hosts:map[string]config.HostRequest(nil)}, login:config.Login{URL:\"\", Form:\"\", Fields:map[string]string(nil), SuccessStatus:0, SuccessURL:\"\", SuccessCookie:\"\"}, proxy:config.Proxy{Proxies:[]string(nil), Rules:[]config.ProxyRule(nil), RoundRobin:false}, tls:config.TLS{CAFiles:[]string(nil), InsecureSkipVerify:false, ClientCerts:map[string]config.ClientCert(nil), ExpiryDays:0}, content:config.Content{MaxBodySize:0, Types:[]string(nil), HeadBinaries:false, BinaryExtensions:[]string(nil)}, links:config.Links{Rules:[]config.LinkRule(nil), Follow:[]string(nil), Report:[]string(nil)}, ignoreRobots:false, directives:config.Directives{IgnoreNoFollow:false, IgnoreCanonical:false}, sitemaps:config.Sitemaps{Discover:false, URLs:[]string(nil)}, sitemapOut:config.SitemapOutput{Path:\"\", Gzip:false, BaseURL:\"\"}, scope:config.Scope{SameHost:false, AllowSubdomains:false, AllowedDomains:[]string(nil), DeniedDomains:[]string(nil), Include:[]string(nil), Exclude:[]string(nil), ReportOutOfScope:false}, canonical:config.Canonicalization{KeepTrackingParams:false, TrackingParams:[]string(nil), FoldTrailingSlash:false, FoldIndex:false}, linkCheck:config.LinkCheck{Enabled:false, External:false, HeadExternal:false}, checkpoint:\"\", resume:false}",
 			wantErr:    false,
 		},
 		{
@@ -1109,6 +1134,30 @@ func Test_configuration_loadFromFile(t *testing.T) {
 	}
 }
 
+func Test_configuration_loadFromFile_values(t *testing.T) {
+	c := &configuration{} //nolint:exhaustivestruct
+
+	err := c.loadFromFile("../../mocks/config.yaml")
+	assert.Nil(t, err)
+
+	assert.Equal(t, "https://go.test", c.URL())
+	assert.Equal(t, uint64(3), c.MaxDepth())
+	assert.Equal(t, 5, c.Timeout())
+	assert.Equal(t, 4, c.Workers())
+	assert.Equal(t, zerolog.InfoLevel, c.LogLevel())
+	assert.Equal(t, 2.0, c.Politeness().Rate)
+	assert.Equal(t, 500*time.Millisecond, c.Politeness().Delay)
+	assert.Equal(t, 0.5, c.Politeness().ForHost("slow.example.com").Rate)
+	assert.Equal(t, time.Second, c.Politeness().ForHost("slow.example.com").RandomDelay)
+	assert.Equal(t, "test-bot/2.0", c.UserAgent())
+	assert.True(t, c.IgnoreRobots())
+	assert.True(t, c.Scope().SameHost)
+	assert.Equal(t, []string{"cdn.example.com"}, c.Scope().AllowedDomains)
+	assert.Equal(t, []string{`\.pdf$`}, c.Scope().Exclude)
+	assert.Equal(t, []string{"ref"}, c.Canonicalization().TrackingParams)
+	assert.True(t, c.Canonicalization().FoldIndex)
+}
+
 func Test_configuration_loadFromFlags(t *testing.T) {
 	type args struct {
 		fc configuration
@@ -1161,11 +1210,83 @@ func Test_configuration_loadFromFlags(t *testing.T) {
 				withPanic:    tt.fields.withPanic,
 				logLevel:     tt.fields.logLevel,
 			}
-			c.loadFromFlags(tt.args.fc)
+			c.loadFromFlags(tt.args.fc, map[string]bool{"m": true, "t": true})
+			assert.Equal(t, tt.args.fc.maxDepth, c.maxDepth)
+			assert.Equal(t, tt.args.fc.timeout, c.timeout)
+			assert.Equal(t, tt.fields.depthIncStep, c.depthIncStep, "flags which are not given are not applied")
 		})
 	}
 }
 
+func Test_load(t *testing.T) {
+	for _, name := range []string{"URL", "OUTPUT", "USER_AGENT", "MAX_DEPTH", "DEPTH_INC_STEP", "TIMEOUT", "WORKERS", "LOG_LEVEL"} {
+		t.Setenv(name, "")
+	}
+
+	fc, path, given, err := readFlags(flag.NewFlagSet("crawler", flag.ContinueOnError), []string{"-c", "../../mocks/config.yaml"})
+	assert.Nil(t, err)
+
+	c, err := load(fc, path, given)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, c.Workers(), "the file is not overridden by the defaults of the flags")
+	assert.Equal(t, uint64(3), c.MaxDepth())
+	assert.Equal(t, 5, c.Timeout())
+	assert.Equal(t, DefaultDepthStep, c.DepthIncStep())
+
+	fc, path, given, err = readFlags(flag.NewFlagSet("crawler", flag.ContinueOnError),
+		[]string{"-c", "../../mocks/config.yaml", "-w", "2", "-m", "0"})
+	assert.Nil(t, err)
+
+	c, err = load(fc, path, given)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, c.Workers(), "the given flags override the file")
+	assert.Equal(t, uint64(0), c.MaxDepth())
+	assert.Equal(t, 5, c.Timeout())
+}
+
+func Test_load_resume(t *testing.T) {
+	for _, name := range []string{"URL", "OUTPUT", "USER_AGENT", "MAX_DEPTH", "DEPTH_INC_STEP", "TIMEOUT", "WORKERS", "LOG_LEVEL"} {
+		t.Setenv(name, "")
+	}
+
+	dir := t.TempDir()
+
+	fc, path, given, err := readFlags(flag.NewFlagSet("crawler", flag.ContinueOnError),
+		[]string{"-c", "../../mocks/config.yaml", "-u", "https://go.test/", "-u", "https://other.test/", "--check-links",
+			"--checkpoint", dir, "-H", "Authorization: Bearer s3cret", "-H", "X-Api-Key: ${CRAWLER_TEST_KEY}"})
+	assert.Nil(t, err)
+
+	c, err := load(fc, path, given)
+	assert.Nil(t, err)
+
+	snapshot, err := c.Snapshot()
+	assert.Nil(t, err)
+	assert.NotContains(t, string(snapshot), "s3cret", "literal header values are not saved")
+
+	store, err := checkpoint.Open(dir, false)
+	assert.Nil(t, err)
+	assert.Nil(t, store.SaveConfig(snapshot))
+	assert.Nil(t, store.Close())
+
+	fc, path, given, err = readFlags(flag.NewFlagSet("crawler", flag.ContinueOnError), []string{"--resume", dir, "-w", "2"})
+	assert.Nil(t, err)
+
+	resumed, err := load(fc, path, given)
+	assert.Nil(t, err)
+	assert.True(t, resumed.Resume())
+	assert.Equal(t, 2, resumed.Workers(), "the given flags override the saved configuration")
+	assert.Equal(t, "https://go.test/", resumed.URL())
+	assert.Equal(t, c.Seeds(), resumed.Seeds())
+	assert.Equal(t, uint64(3), resumed.MaxDepth())
+	assert.Equal(t, 5, resumed.Timeout())
+	assert.Equal(t, c.Politeness(), resumed.Politeness())
+	assert.Equal(t, c.Scope().AllowedDomains, resumed.Scope().AllowedDomains)
+	assert.Equal(t, c.Scope().Exclude, resumed.Scope().Exclude)
+	assert.True(t, resumed.LinkCheck().Enabled)
+	assert.Equal(t, map[string]string{"X-Api-Key": "${CRAWLER_TEST_KEY}"}, resumed.Request().Headers)
+	assert.Equal(t, dir, resumed.CheckpointDir())
+}
+
 func Test_configuration_validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -1223,6 +1344,14 @@ func Test_configuration_validate(t *testing.T) {
 	}
 }
 
+func Test_configuration_validateResume(t *testing.T) {
+	c := &configuration{checkpoint: "state", resume: true} //nolint:exhaustivestruct
+	assert.Nil(t, c.validate(), "resume without url")
+
+	c.resume = false
+	assert.NotNil(t, c.validate(), "checkpoint without url")
+}
+
 func Test_fillEnv(t *testing.T) {
 	tests := []struct {
 		name    string
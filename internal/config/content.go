@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+)
+
+// DefaultMaxBodySize - bytes read from a response body by default
+const DefaultMaxBodySize = 10 << 20
+
+// Content - which responses are downloaded and parsed for links
+type Content struct {
+	// MaxBodySize - bytes read from a response body, the rest is not downloaded; negative for no limit
+	MaxBodySize int64 `yaml:"max_body_size"`
+	// Types - media types parsed for links, bodies of other types are not downloaded
+	Types []string `yaml:"types"`
+	// HeadBinaries - checks urls with binary extensions with HEAD and downloads them only if they are parsed
+	HeadBinaries bool `yaml:"head_binaries"`
+	// BinaryExtensions - extensions of the urls checked with HEAD first
+	BinaryExtensions []string `yaml:"binary_extensions"`
+}
+
+func DefaultContent() Content {
+	return Content{
+		MaxBodySize:  DefaultMaxBodySize,
+		Types:        []string{"text/html", "application/xhtml+xml"},
+		HeadBinaries: false,
+		BinaryExtensions: []string{
+			".7z", ".avi", ".bin", ".dmg", ".doc", ".docx", ".exe", ".gif", ".gz", ".iso", ".jpeg", ".jpg", ".mkv",
+			".mov", ".mp3", ".mp4", ".msi", ".pdf", ".png", ".ppt", ".pptx", ".rar", ".tar", ".tgz", ".webp", ".xls",
+			".xlsx", ".zip",
+		},
+	}
+}
+
+// Parsed - reports whether responses of the media type are parsed for links
+func (c Content) Parsed(mediaType string) bool {
+	for _, t := range c.Types {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Binary - reports whether the url path has one of the binary extensions
+func (c Content) Binary(path string) bool {
+	path = strings.ToLower(path)
+
+	for _, ext := range c.BinaryExtensions {
+		if strings.HasSuffix(path, strings.ToLower(ext)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Content) merge(o Content) {
+	if o.MaxBodySize != 0 {
+		c.MaxBodySize = o.MaxBodySize
+	}
+
+	if len(o.Types) > 0 {
+		c.Types = o.Types
+	}
+
+	if o.HeadBinaries {
+		c.HeadBinaries = o.HeadBinaries
+	}
+
+	if len(o.BinaryExtensions) > 0 {
+		c.BinaryExtensions = o.BinaryExtensions
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContent_Parsed(t *testing.T) {
+	c := DefaultContent()
+
+	assert.True(t, c.Parsed("text/html"))
+	assert.True(t, c.Parsed("Application/XHTML+XML"))
+	assert.False(t, c.Parsed("application/pdf"))
+	assert.False(t, c.Parsed(""))
+}
+
+func TestContent_Binary(t *testing.T) {
+	c := DefaultContent()
+
+	assert.True(t, c.Binary("/files/ubuntu.ISO"))
+	assert.True(t, c.Binary("/report.pdf"))
+	assert.False(t, c.Binary("/index.html"))
+	assert.False(t, c.Binary("/pdf"))
+}
+
+func TestContent_merge(t *testing.T) {
+	c := DefaultContent()
+	c.merge(Content{MaxBodySize: -1, Types: []string{"text/html"}, HeadBinaries: true, BinaryExtensions: nil})
+
+	assert.Equal(t, int64(-1), c.MaxBodySize)
+	assert.Equal(t, []string{"text/html"}, c.Types)
+	assert.True(t, c.HeadBinaries)
+	assert.Equal(t, DefaultContent().BinaryExtensions, c.BinaryExtensions)
+}
@@ -0,0 +1,21 @@
+package config
+
+// Directives - which robots directives and canonical links of the pages are honored
+type Directives struct {
+	// IgnoreNoFollow - follows links marked with rel="nofollow" and links of pages with a nofollow
+	// meta robots tag or X-Robots-Tag header
+	IgnoreNoFollow bool `yaml:"ignore_nofollow"`
+	// IgnoreCanonical - follows links of pages whose canonical url is another page in the scope,
+	// only the canonical link of such pages is followed otherwise
+	IgnoreCanonical bool `yaml:"ignore_canonical"`
+}
+
+func (d *Directives) merge(o Directives) {
+	if o.IgnoreNoFollow {
+		d.IgnoreNoFollow = o.IgnoreNoFollow
+	}
+
+	if o.IgnoreCanonical {
+		d.IgnoreCanonical = o.IgnoreCanonical
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"github.com/rs/zerolog"
+)
+
+// fileConfiguration - mirrors configuration with exported fields so it can be decoded from yaml
+type fileConfiguration struct {
+	URL              string           `yaml:"url"`
+	Seeds            []Seed           `yaml:"seeds"`
+	SeedsFile        string           `yaml:"seeds_file"`
+	MaxDepth         uint64           `yaml:"max_depth"`
+	Timeout          int              `yaml:"timeout"`
+	DepthIncStep     int              `yaml:"depth_inc_step"`
+	Output           string           `yaml:"output"`
+	Format           string           `yaml:"format"`
+	Delimiter        string           `yaml:"delimiter"`
+	Columns          []string         `yaml:"columns"`
+	JSONLog          bool             `yaml:"json_log"`
+	WithPanic        bool             `yaml:"with_panic"`
+	LogLevel         string           `yaml:"log_level"`
+	Workers          int              `yaml:"workers"`
+	Politeness       Politeness       `yaml:"politeness"`
+	Transport        Transport        `yaml:"transport"`
+	Retry            Retry            `yaml:"retry"`
+	UserAgent        string           `yaml:"user_agent"`
+	Request          Request          `yaml:"request"`
+	Login            Login            `yaml:"login"`
+	Proxy            Proxy            `yaml:"proxy"`
+	TLS              TLS              `yaml:"tls"`
+	Content          Content          `yaml:"content"`
+	Links            Links            `yaml:"links"`
+	IgnoreRobots     bool             `yaml:"ignore_robots"`
+	Directives       Directives       `yaml:"directives"`
+	Sitemaps         Sitemaps         `yaml:"sitemaps"`
+	SitemapOutput    SitemapOutput    `yaml:"sitemap_output"`
+	Scope            Scope            `yaml:"scope"`
+	Canonicalization Canonicalization `yaml:"canonicalization"`
+	LinkCheck        LinkCheck        `yaml:"check_links"`
+	Checkpoint       string           `yaml:"checkpoint"`
+}
+
+func (fc fileConfiguration) configuration() (c configuration, err error) {
+	c = configuration{ //nolint:exhaustivestruct
+		url:          fc.URL,
+		seeds:        fc.Seeds,
+		seedsFile:    fc.SeedsFile,
+		maxDepth:     fc.MaxDepth,
+		timeout:      fc.Timeout,
+		depthIncStep: fc.DepthIncStep,
+		output:       fc.Output,
+		format:       fc.Format,
+		delimiter:    fc.Delimiter,
+		columns:      fc.Columns,
+		jsonLog:      fc.JSONLog,
+		withPanic:    fc.WithPanic,
+		workers:      fc.Workers,
+		politeness:   fc.Politeness,
+		transport:    fc.Transport,
+		retry:        fc.Retry,
+		userAgent:    fc.UserAgent,
+		request:      fc.Request,
+		login:        fc.Login,
+		proxy:        fc.Proxy,
+		tls:          fc.TLS,
+		content:      fc.Content,
+		links:        fc.Links,
+		ignoreRobots: fc.IgnoreRobots,
+		directives:   fc.Directives,
+		sitemaps:     fc.Sitemaps,
+		sitemapOut:   fc.SitemapOutput,
+		scope:        fc.Scope,
+		canonical:    fc.Canonicalization,
+		linkCheck:    fc.LinkCheck,
+		checkpoint:   fc.Checkpoint,
+	}
+
+	if fc.LogLevel != "" {
+		if c.logLevel, err = zerolog.ParseLevel(fc.LogLevel); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// fileConfiguration - returns the values of c in the form they are read from yaml, the seeds file is already read
+// to the seeds so it is not kept
+func (c *configuration) fileConfiguration() fileConfiguration {
+	return fileConfiguration{
+		URL:              c.url,
+		Seeds:            c.seeds,
+		SeedsFile:        "",
+		MaxDepth:         c.maxDepth,
+		Timeout:          c.timeout,
+		DepthIncStep:     c.depthIncStep,
+		Output:           c.output,
+		Format:           c.format,
+		Delimiter:        c.delimiter,
+		Columns:          c.columns,
+		JSONLog:          c.jsonLog,
+		WithPanic:        c.withPanic,
+		LogLevel:         c.logLevel.String(),
+		Workers:          c.workers,
+		Politeness:       c.politeness,
+		Transport:        c.transport,
+		Retry:            c.retry,
+		UserAgent:        c.userAgent,
+		Request:          c.request,
+		Login:            c.login,
+		Proxy:            c.proxy,
+		TLS:              c.tls,
+		Content:          c.content,
+		Links:            c.links,
+		IgnoreRobots:     c.ignoreRobots,
+		Directives:       c.directives,
+		Sitemaps:         c.sitemaps,
+		SitemapOutput:    c.sitemapOut,
+		Scope:            c.scope,
+		Canonicalization: c.canonical,
+		LinkCheck:        c.linkCheck,
+		Checkpoint:       c.checkpoint,
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Output formats of the crawl results
+const (
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+	FormatJSONL = "jsonl"
+	FormatJSON  = "json"
+	FormatXML   = "xml"
+	// DefaultDelimiter - separator of the csv values
+	DefaultDelimiter = ';'
+)
+
+// AvailableFormats - returns all supported output formats
+func AvailableFormats() []string {
+	return []string{FormatCSV, FormatTSV, FormatJSONL, FormatJSON, FormatXML}
+}
+
+// formatOf - returns the format of the output file detected from its extension, empty if it is unknown
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".tsv", ".tab":
+		return FormatTSV
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".json":
+		return FormatJSON
+	case ".xml":
+		return FormatXML
+	default:
+		return ""
+	}
+}
+
+func validateFormat(format string) error {
+	for _, f := range AvailableFormats() {
+		if f == format {
+			return nil
+		}
+	}
+
+	return errors.New("unknown output format (" + format + "), available: " + strings.Join(AvailableFormats(), ", "))
+}
+
+// validateDelimiter - the delimiter is a single character which is neither a quote nor a line break
+func validateDelimiter(delimiter string) error {
+	if delimiter == "" {
+		return nil
+	}
+
+	r, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return errors.New("wrong csv delimiter (" + delimiter + "), a single character except quotes and line breaks is expected")
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfiguration_Format(t *testing.T) {
+	assert.Equal(t, FormatCSV, (&configuration{}).Format())                                     //nolint:exhaustivestruct
+	assert.Equal(t, FormatJSONL, (&configuration{output: "result.JSONL"}).Format())             //nolint:exhaustivestruct
+	assert.Equal(t, FormatTSV, (&configuration{output: "result.tsv"}).Format())                 //nolint:exhaustivestruct
+	assert.Equal(t, FormatCSV, (&configuration{output: "result.txt"}).Format())                 //nolint:exhaustivestruct
+	assert.Equal(t, FormatXML, (&configuration{output: "result.json", format: "XML"}).Format()) //nolint:exhaustivestruct
+}
+
+func TestConfiguration_Delimiter(t *testing.T) {
+	assert.Equal(t, rune(DefaultDelimiter), (&configuration{}).Delimiter()) //nolint:exhaustivestruct
+	assert.Equal(t, '|', (&configuration{delimiter: "|"}).Delimiter())      //nolint:exhaustivestruct
+}
+
+func TestConfiguration_validateFormat(t *testing.T) {
+	assert.Nil(t, (&configuration{output: "result.xml"}).validateFormat())                  //nolint:exhaustivestruct
+	assert.Nil(t, (&configuration{delimiter: ","}).validateFormat())                        //nolint:exhaustivestruct
+	assert.Nil(t, (&configuration{output: "result.jsonl", resume: true}).validateFormat())  //nolint:exhaustivestruct
+	assert.Error(t, (&configuration{format: "yaml"}).validateFormat())                      //nolint:exhaustivestruct
+	assert.Error(t, (&configuration{delimiter: ",,"}).validateFormat())                     //nolint:exhaustivestruct
+	assert.Error(t, (&configuration{delimiter: "\""}).validateFormat())                     //nolint:exhaustivestruct
+	assert.Error(t, (&configuration{output: "result.json", resume: true}).validateFormat()) //nolint:exhaustivestruct
+}
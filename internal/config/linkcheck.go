@@ -0,0 +1,25 @@
+package config
+
+// LinkCheck - settings of the broken link checker mode
+type LinkCheck struct {
+	// Enabled - reports broken links grouped by the pages linking to them instead of titles
+	Enabled bool `yaml:"enabled"`
+	// External - also checks links out of the crawl scope without crawling them further
+	External bool `yaml:"external"`
+	// HeadExternal - checks external links with HEAD requests
+	HeadExternal bool `yaml:"head_external"`
+}
+
+func (l *LinkCheck) merge(o LinkCheck) {
+	if o.Enabled {
+		l.Enabled = o.Enabled
+	}
+
+	if o.External {
+		l.External = o.External
+	}
+
+	if o.HeadExternal {
+		l.HeadExternal = o.HeadExternal
+	}
+}
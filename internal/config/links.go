@@ -0,0 +1,134 @@
+package config
+
+import (
+	"github.com/andybalholm/cascadia"
+	"github.com/pkg/errors"
+)
+
+// Kinds of the extracted links
+const (
+	// LinkNavigation - link to another page, e.g. <a href> or <iframe src>
+	LinkNavigation = "navigation"
+	// LinkAsset - resource of the page, e.g. an image, a script or a stylesheet
+	LinkAsset = "asset"
+	// LinkAlternate - alternative version of the page, e.g. a translation or a feed
+	LinkAlternate = "alternate"
+	// LinkCanonical - preferred url of the page
+	LinkCanonical = "canonical"
+)
+
+// Formats of the link values
+const (
+	// FormatURL - the value is a single url
+	FormatURL = "url"
+	// FormatSrcset - the value is a list of image candidates, e.g. "a.png 1x, b.png 2x"
+	FormatSrcset = "srcset"
+	// FormatCSS - the value is a style sheet with url() references
+	FormatCSS = "css"
+	// FormatRefresh - the value is the content of a refresh meta tag, e.g. "5; url=/next"
+	FormatRefresh = "refresh"
+)
+
+// LinkRule - extracts links of a kind from the elements matching the selector
+type LinkRule struct {
+	// Selector - css selector of the elements
+	Selector string `yaml:"selector"`
+	// Attr - attribute holding the link, empty for the text of the element
+	Attr string `yaml:"attr"`
+	// Format - format of the value: url (default), srcset, css or refresh
+	Format string `yaml:"format"`
+	// Kind - kind of the extracted links
+	Kind string `yaml:"kind"`
+}
+
+// Links - extraction of the links of a page and the kinds of links crawled further
+type Links struct {
+	// Rules - checked in order, the first rule matching an attribute of an element is used
+	Rules []LinkRule `yaml:"rules"`
+	// Follow - kinds of links which are crawled
+	Follow []string `yaml:"follow"`
+	// Report - kinds of links which are output without being crawled, in the link check mode they are checked once
+	Report []string `yaml:"report"`
+}
+
+// DefaultLinkRules - returns the rules extracting links of the html elements referencing other urls
+func DefaultLinkRules() []LinkRule {
+	return []LinkRule{
+		{Selector: "a[href]", Attr: "href", Format: FormatURL, Kind: LinkNavigation},
+		{Selector: "area[href]", Attr: "href", Format: FormatURL, Kind: LinkNavigation},
+		{Selector: "iframe[src]", Attr: "src", Format: FormatURL, Kind: LinkNavigation},
+		{Selector: "frame[src]", Attr: "src", Format: FormatURL, Kind: LinkNavigation},
+		{Selector: `meta[http-equiv="refresh" i][content]`, Attr: "content", Format: FormatRefresh, Kind: LinkNavigation},
+		{Selector: `link[rel~="canonical" i][href]`, Attr: "href", Format: FormatURL, Kind: LinkCanonical},
+		{Selector: `link[rel~="alternate" i][href]`, Attr: "href", Format: FormatURL, Kind: LinkAlternate},
+		{Selector: "link[href]", Attr: "href", Format: FormatURL, Kind: LinkAsset},
+		{Selector: "img[src]", Attr: "src", Format: FormatURL, Kind: LinkAsset},
+		{Selector: "img[srcset]", Attr: "srcset", Format: FormatSrcset, Kind: LinkAsset},
+		{Selector: "script[src]", Attr: "src", Format: FormatURL, Kind: LinkAsset},
+		{Selector: "source[src]", Attr: "src", Format: FormatURL, Kind: LinkAsset},
+		{Selector: "source[srcset]", Attr: "srcset", Format: FormatSrcset, Kind: LinkAsset},
+		{Selector: "video[src], audio[src], embed[src]", Attr: "src", Format: FormatURL, Kind: LinkAsset},
+		{Selector: "video[poster]", Attr: "poster", Format: FormatURL, Kind: LinkAsset},
+		{Selector: "style", Attr: "", Format: FormatCSS, Kind: LinkAsset},
+		{Selector: "[style]", Attr: "style", Format: FormatCSS, Kind: LinkAsset},
+	}
+}
+
+func DefaultLinks() Links {
+	return Links{
+		Rules:  DefaultLinkRules(),
+		Follow: []string{LinkNavigation, LinkCanonical},
+		Report: nil,
+	}
+}
+
+func (l *Links) merge(o Links) {
+	if len(o.Rules) > 0 {
+		l.Rules = o.Rules
+	}
+
+	if len(o.Follow) > 0 {
+		l.Follow = o.Follow
+	}
+
+	if len(o.Report) > 0 {
+		l.Report = o.Report
+	}
+}
+
+func (l Links) validate() error {
+	for _, rule := range l.Rules {
+		if _, err := cascadia.Compile(rule.Selector); err != nil {
+			return errors.Wrap(err, "wrong link selector ("+rule.Selector+")")
+		}
+
+		switch rule.Format {
+		case "", FormatURL, FormatSrcset, FormatCSS, FormatRefresh:
+		default:
+			return errors.New("wrong link format (" + rule.Format + ")")
+		}
+
+		if err := validateLinkKind(rule.Kind); err != nil {
+			return err
+		}
+	}
+
+	for _, kinds := range [][]string{l.Follow, l.Report} {
+		for _, kind := range kinds {
+			if err := validateLinkKind(kind); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateLinkKind(kind string) error {
+	switch kind {
+	case LinkNavigation, LinkAsset, LinkAlternate, LinkCanonical:
+		return nil
+	default:
+		return errors.New("wrong link kind (" + kind + ")")
+	}
+}
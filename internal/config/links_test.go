@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinks_validate(t *testing.T) {
+	assert.Nil(t, DefaultLinks().validate())
+
+	tests := []struct {
+		name  string
+		links Links
+	}{
+		{name: "selector", links: Links{Rules: []LinkRule{{Selector: "a[", Attr: "href", Format: "", Kind: LinkNavigation}}}},  //nolint:exhaustivestruct
+		{name: "format", links: Links{Rules: []LinkRule{{Selector: "a", Attr: "href", Format: "json", Kind: LinkNavigation}}}}, //nolint:exhaustivestruct
+		{name: "kind", links: Links{Rules: []LinkRule{{Selector: "a", Attr: "href", Format: "", Kind: "page"}}}},               //nolint:exhaustivestruct
+		{name: "follow", links: Links{Follow: []string{"assets"}}},                                                             //nolint:exhaustivestruct
+		{name: "report", links: Links{Report: []string{""}}},                                                                   //nolint:exhaustivestruct
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.links.validate())
+		})
+	}
+}
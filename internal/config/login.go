@@ -0,0 +1,93 @@
+package config
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultLoginForm - selector of the login form when none is configured
+const DefaultLoginForm = "form"
+
+// Login - form login performed before the crawl and repeated when the crawler is redirected to the login page,
+// field values may reference environment variables as ${NAME}
+type Login struct {
+	// URL - page with the login form, empty disables the login
+	URL string `yaml:"url"`
+	// Form - css selector of the login form
+	Form string `yaml:"form"`
+	// Fields - values of the form fields by name, other fields keep the values of the page, e.g. csrf tokens
+	Fields map[string]string `yaml:"fields"`
+	// SuccessStatus - status of the response to the submitted form required for a successful login
+	SuccessStatus int `yaml:"success_status"`
+	// SuccessURL - substring of the url after the redirects required for a successful login
+	SuccessURL string `yaml:"success_url"`
+	// SuccessCookie - cookie which must be set for a successful login
+	SuccessCookie string `yaml:"success_cookie"`
+}
+
+// Enabled - reports whether the login is configured
+func (l Login) Enabled() bool {
+	return l.URL != ""
+}
+
+// Resolve - returns the settings with the environment variable references of the fields replaced by their values
+func (l Login) Resolve() (resolved Login, err error) {
+	resolved = l
+
+	if resolved.Fields, err = expandValues(l.Fields); err != nil {
+		return resolved, err
+	}
+
+	return resolved, nil
+}
+
+// snapshot - returns the settings with the fields referencing environment variables only
+func (l Login) snapshot() Login {
+	saved := l
+	saved.Fields = referencedValues(l.Fields)
+
+	return saved
+}
+
+func (l *Login) merge(o Login) {
+	if o.URL != "" {
+		l.URL = o.URL
+	}
+
+	if o.Form != "" {
+		l.Form = o.Form
+	}
+
+	for name, value := range o.Fields {
+		if l.Fields == nil {
+			l.Fields = make(map[string]string, len(o.Fields))
+		}
+
+		l.Fields[name] = value
+	}
+
+	if o.SuccessStatus != 0 {
+		l.SuccessStatus = o.SuccessStatus
+	}
+
+	if o.SuccessURL != "" {
+		l.SuccessURL = o.SuccessURL
+	}
+
+	if o.SuccessCookie != "" {
+		l.SuccessCookie = o.SuccessCookie
+	}
+}
+
+func (l Login) validate() error {
+	if !l.Enabled() {
+		return nil
+	}
+
+	if _, err := url.ParseRequestURI(l.URL); err != nil {
+		return errors.Wrap(err, "wrong login url ("+l.URL+")")
+	}
+
+	return nil
+}
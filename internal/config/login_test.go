@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogin_Resolve(t *testing.T) {
+	t.Setenv("CRAWLER_TEST_PASSWORD", "secret")
+
+	l := Login{ //nolint:exhaustivestruct
+		URL:    "https://go.test/login",
+		Fields: map[string]string{"user": "admin", "password": "${CRAWLER_TEST_PASSWORD}"},
+	}
+
+	resolved, err := l.Resolve()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"user": "admin", "password": "secret"}, resolved.Fields)
+	assert.Equal(t, "${CRAWLER_TEST_PASSWORD}", l.Fields["password"])
+}
+
+func TestLogin_snapshot(t *testing.T) {
+	l := Login{ //nolint:exhaustivestruct
+		URL:    "https://go.test/login",
+		Fields: map[string]string{"user": "admin", "password": "${CRAWLER_TEST_PASSWORD}", "pin": "1234"},
+	}
+
+	saved := l.snapshot()
+	assert.Equal(t, "https://go.test/login", saved.URL)
+	assert.Equal(t, map[string]string{"password": "${CRAWLER_TEST_PASSWORD}"}, saved.Fields)
+	assert.Len(t, l.Fields, 3, "the settings are not changed")
+}
+
+func TestLogin_validate(t *testing.T) {
+	assert.Nil(t, Login{}.validate())                             //nolint:exhaustivestruct
+	assert.Nil(t, Login{URL: "https://go.test/login"}.validate()) //nolint:exhaustivestruct
+	assert.Error(t, Login{URL: "login"}.validate())               //nolint:exhaustivestruct
+	assert.False(t, Login{}.Enabled())                            //nolint:exhaustivestruct
+	assert.True(t, Login{URL: "https://go.test/login"}.Enabled()) //nolint:exhaustivestruct
+}
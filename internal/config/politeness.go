@@ -0,0 +1,98 @@
+package config
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultHostRate        = 5.0
+	DefaultHostBurst       = 1
+	DefaultHostConcurrency = 2
+)
+
+// HostPoliteness - throttling rules applied to every request to a single host
+type HostPoliteness struct {
+	// Rate - requests per second allowed for the host, negative value means unlimited
+	Rate float64 `yaml:"rate"`
+	// Burst - number of requests that may be sent at once before the rate applies
+	Burst int `yaml:"burst"`
+	// MaxConcurrent - number of requests to the host in flight at the same time, negative value means unlimited
+	MaxConcurrent int `yaml:"max_concurrent"`
+	// Delay - fixed pause between two consecutive requests to the host
+	Delay time.Duration `yaml:"delay"`
+	// RandomDelay - upper bound of a random pause added to Delay
+	RandomDelay time.Duration `yaml:"random_delay"`
+}
+
+// Politeness - global throttling rules with per-host overrides
+type Politeness struct {
+	HostPoliteness `yaml:",inline"`
+	Hosts          map[string]HostPoliteness `yaml:"hosts"`
+}
+
+func DefaultPoliteness() Politeness {
+	return Politeness{
+		HostPoliteness: HostPoliteness{
+			Rate:          DefaultHostRate,
+			Burst:         DefaultHostBurst,
+			MaxConcurrent: DefaultHostConcurrency,
+			Delay:         0,
+			RandomDelay:   0,
+		},
+		Hosts: nil,
+	}
+}
+
+// ForHost - returns the global rules overridden by the non-zero rules configured for host
+func (p Politeness) ForHost(host string) HostPoliteness {
+	rules := p.HostPoliteness
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	override, ok := p.Hosts[strings.ToLower(host)]
+	if !ok {
+		return rules
+	}
+
+	rules.merge(override)
+
+	return rules
+}
+
+func (h *HostPoliteness) merge(o HostPoliteness) {
+	if o.Rate != 0 {
+		h.Rate = o.Rate
+	}
+
+	if o.Burst != 0 {
+		h.Burst = o.Burst
+	}
+
+	if o.MaxConcurrent != 0 {
+		h.MaxConcurrent = o.MaxConcurrent
+	}
+
+	if o.Delay != 0 {
+		h.Delay = o.Delay
+	}
+
+	if o.RandomDelay != 0 {
+		h.RandomDelay = o.RandomDelay
+	}
+}
+
+func (p *Politeness) merge(o Politeness) {
+	p.HostPoliteness.merge(o.HostPoliteness)
+
+	for host, rules := range o.Hosts {
+		if p.Hosts == nil {
+			p.Hosts = make(map[string]HostPoliteness, len(o.Hosts))
+		}
+
+		p.Hosts[strings.ToLower(host)] = rules
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoliteness_ForHost(t *testing.T) {
+	p := DefaultPoliteness()
+	p.merge(Politeness{
+		HostPoliteness: HostPoliteness{Rate: 0, Burst: 0, MaxConcurrent: 0, Delay: time.Second, RandomDelay: 0},
+		Hosts: map[string]HostPoliteness{
+			"Slow.Go.Test": {Rate: 0.5, Burst: 0, MaxConcurrent: 0, Delay: 0, RandomDelay: 0},
+		},
+	})
+
+	assert.Equal(t, HostPoliteness{
+		Rate:          DefaultHostRate,
+		Burst:         DefaultHostBurst,
+		MaxConcurrent: DefaultHostConcurrency,
+		Delay:         time.Second,
+		RandomDelay:   0,
+	}, p.ForHost("go.test"))
+
+	assert.Equal(t, HostPoliteness{
+		Rate:          0.5,
+		Burst:         DefaultHostBurst,
+		MaxConcurrent: DefaultHostConcurrency,
+		Delay:         time.Second,
+		RandomDelay:   0,
+	}, p.ForHost("slow.go.test:8080"))
+}
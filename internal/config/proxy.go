@@ -0,0 +1,161 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DirectProxy - proxy value sending the requests without a proxy
+const DirectProxy = "direct"
+
+// ProxyRule - proxies of the hosts matching the patterns
+type ProxyRule struct {
+	// Hosts - host names, "*.example.com" matches the subdomains of example.com and "*" every host
+	Hosts []string `yaml:"hosts"`
+	// Proxies - http, https or socks5 proxy urls with optional credentials, or "direct"
+	Proxies []string `yaml:"proxies"`
+}
+
+// Proxy - proxies of the requests, urls may reference environment variables as ${NAME}
+type Proxy struct {
+	// Proxies - proxies of the hosts matched by no rule, NO_PROXY still applies to them;
+	// empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	Proxies []string `yaml:"proxies"`
+	// Rules - checked in order, the first rule matching the host is used
+	Rules []ProxyRule `yaml:"rules"`
+	// RoundRobin - rotates through the proxies of a list with every request, otherwise the first one is used
+	RoundRobin bool `yaml:"round_robin"`
+}
+
+// Resolve - returns the settings with the environment variable references replaced by their values
+func (p Proxy) Resolve() (resolved Proxy, err error) {
+	resolved = Proxy{Proxies: nil, Rules: nil, RoundRobin: p.RoundRobin}
+
+	if resolved.Proxies, err = expandList(p.Proxies); err != nil {
+		return resolved, err
+	}
+
+	for _, rule := range p.Rules {
+		r := ProxyRule{Hosts: rule.Hosts, Proxies: nil}
+
+		if r.Proxies, err = expandList(rule.Proxies); err != nil {
+			return resolved, err
+		}
+
+		resolved.Rules = append(resolved.Rules, r)
+	}
+
+	return resolved, nil
+}
+
+// snapshot - returns the settings without the proxies with literal credentials
+func (p Proxy) snapshot() Proxy {
+	saved := Proxy{Proxies: withoutCredentials(p.Proxies), Rules: nil, RoundRobin: p.RoundRobin}
+
+	for _, rule := range p.Rules {
+		if proxies := withoutCredentials(rule.Proxies); len(proxies) > 0 {
+			saved.Rules = append(saved.Rules, ProxyRule{Hosts: rule.Hosts, Proxies: proxies})
+		}
+	}
+
+	return saved
+}
+
+func (p *Proxy) merge(o Proxy) {
+	if len(o.Proxies) > 0 {
+		p.Proxies = o.Proxies
+	}
+
+	if len(o.Rules) > 0 {
+		p.Rules = o.Rules
+	}
+
+	if o.RoundRobin {
+		p.RoundRobin = o.RoundRobin
+	}
+}
+
+func (p Proxy) validate() error {
+	if err := validateProxies(p.Proxies); err != nil {
+		return err
+	}
+
+	for _, rule := range p.Rules {
+		if len(rule.Hosts) == 0 || len(rule.Proxies) == 0 {
+			return errors.New("proxy rule must have hosts and proxies")
+		}
+
+		if err := validateProxies(rule.Proxies); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateProxies - checks the proxy urls, environment variable references are not resolved yet
+func validateProxies(proxies []string) error {
+	for _, proxy := range proxies {
+		if proxy == DirectProxy {
+			continue
+		}
+
+		u, err := url.Parse(envReference.ReplaceAllString(proxy, "env"))
+		if err != nil {
+			return errors.Wrap(err, "wrong proxy ("+proxy+")")
+		}
+
+		switch strings.ToLower(u.Scheme) {
+		case "http", "https", "socks5":
+		default:
+			return errors.New("wrong proxy (" + proxy + "): scheme must be http, https or socks5")
+		}
+
+		if u.Host == "" {
+			return errors.New("wrong proxy (" + proxy + "): no host")
+		}
+	}
+
+	return nil
+}
+
+// withoutCredentials - returns the proxies without credentials or with credentials referencing environment variables
+func withoutCredentials(proxies []string) []string {
+	var kept []string
+
+	for _, proxy := range proxies {
+		if envReference.MatchString(proxy) {
+			kept = append(kept, proxy)
+
+			continue
+		}
+
+		if u, err := url.Parse(proxy); err == nil && u.User == nil {
+			kept = append(kept, proxy)
+		}
+	}
+
+	return kept
+}
+
+// expandList - returns a copy of values with the environment variable references expanded
+func expandList(values []string) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+
+	expanded := make([]string, 0, len(values))
+
+	for _, value := range values {
+		v, err := expandEnv(value)
+		if err != nil {
+			return nil, err
+		}
+
+		expanded = append(expanded, v)
+	}
+
+	return expanded, nil
+}
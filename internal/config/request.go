@@ -0,0 +1,220 @@
+package config
+
+import (
+	"net"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// envReference - ${NAME} placeholder replaced with the environment variable value
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`) //nolint:gochecknoglobals
+
+// Auth - credentials sent to a single host, a token is sent as a bearer token, a username with basic auth
+type Auth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+}
+
+// HostRequest - request settings of a single host
+type HostRequest struct {
+	Headers map[string]string `yaml:"headers"`
+	Auth    Auth              `yaml:"auth"`
+}
+
+// Request - settings of every request, values may reference environment variables as ${NAME}
+type Request struct {
+	// Headers - sent with requests to every host
+	Headers map[string]string `yaml:"headers"`
+	// Cookies - keeps cookies set by the servers for the next requests
+	Cookies bool `yaml:"cookies"`
+	// Hosts - headers and credentials of single hosts, sent in addition to the global headers
+	Hosts map[string]HostRequest `yaml:"hosts"`
+}
+
+// ForHost - returns the headers and the credentials of host, host headers override the global ones
+func (r Request) ForHost(host string) HostRequest {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	override := r.Hosts[strings.ToLower(host)]
+	headers := make(map[string]string, len(r.Headers)+len(override.Headers))
+
+	for name, value := range r.Headers {
+		headers[name] = value
+	}
+
+	for name, value := range override.Headers {
+		headers[name] = value
+	}
+
+	return HostRequest{Headers: headers, Auth: override.Auth}
+}
+
+// Resolve - returns the settings with the environment variable references replaced by their values
+func (r Request) Resolve() (resolved Request, err error) {
+	resolved = Request{Headers: nil, Cookies: r.Cookies, Hosts: nil}
+
+	if resolved.Headers, err = expandValues(r.Headers); err != nil {
+		return resolved, err
+	}
+
+	for host, hr := range r.Hosts {
+		if resolved.Hosts == nil {
+			resolved.Hosts = make(map[string]HostRequest, len(r.Hosts))
+		}
+
+		var h HostRequest
+
+		if h.Headers, err = expandValues(hr.Headers); err != nil {
+			return resolved, err
+		}
+
+		if h.Auth.Username, err = expandEnv(hr.Auth.Username); err != nil {
+			return resolved, err
+		}
+
+		if h.Auth.Password, err = expandEnv(hr.Auth.Password); err != nil {
+			return resolved, err
+		}
+
+		if h.Auth.Token, err = expandEnv(hr.Auth.Token); err != nil {
+			return resolved, err
+		}
+
+		resolved.Hosts[host] = h
+	}
+
+	return resolved, nil
+}
+
+// snapshot - returns the settings with the values referencing environment variables only, the literal ones
+// may be secrets
+func (r Request) snapshot() Request {
+	saved := Request{Headers: referencedValues(r.Headers), Cookies: r.Cookies, Hosts: nil}
+
+	for host, hr := range r.Hosts {
+		if saved.Hosts == nil {
+			saved.Hosts = make(map[string]HostRequest, len(r.Hosts))
+		}
+
+		saved.Hosts[host] = HostRequest{
+			Headers: referencedValues(hr.Headers),
+			Auth: Auth{
+				Username: referenced(hr.Auth.Username),
+				Password: referenced(hr.Auth.Password),
+				Token:    referenced(hr.Auth.Token),
+			},
+		}
+	}
+
+	return saved
+}
+
+func (r *Request) merge(o Request) {
+	for name, value := range o.Headers {
+		if r.Headers == nil {
+			r.Headers = make(map[string]string, len(o.Headers))
+		}
+
+		r.Headers[name] = value
+	}
+
+	if o.Cookies {
+		r.Cookies = o.Cookies
+	}
+
+	for host, hr := range o.Hosts {
+		if r.Hosts == nil {
+			r.Hosts = make(map[string]HostRequest, len(o.Hosts))
+		}
+
+		r.Hosts[strings.ToLower(host)] = hr
+	}
+}
+
+// expandValues - returns a copy of the named values with the environment variable references expanded
+func expandValues(headers map[string]string) (map[string]string, error) {
+	if headers == nil {
+		return nil, nil
+	}
+
+	expanded := make(map[string]string, len(headers))
+
+	for name, value := range headers {
+		v, err := expandEnv(value)
+		if err != nil {
+			return nil, err
+		}
+
+		expanded[name] = v
+	}
+
+	return expanded, nil
+}
+
+// referencedValues - returns the named values referencing environment variables
+func referencedValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+
+	referencing := make(map[string]string, len(values))
+
+	for name, value := range values {
+		if envReference.MatchString(value) {
+			referencing[name] = value
+		}
+	}
+
+	return referencing
+}
+
+// referenced - returns the value if it references an environment variable, empty otherwise
+func referenced(value string) string {
+	if envReference.MatchString(value) {
+		return value
+	}
+
+	return ""
+}
+
+// expandEnv - replaces ${NAME} references with the environment variables, unset variables are an error
+func expandEnv(value string) (string, error) {
+	var err error
+
+	expanded := envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+
+		v, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = errors.New("environment variable " + name + " is not set")
+		}
+
+		return v
+	})
+
+	return expanded, err
+}
+
+// headerFlag - collects repeated "Name: value" flags into the global headers
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	return ""
+}
+
+func (h headerFlag) Set(value string) error {
+	parts := strings.SplitN(value, ":", 2) //nolint:gomnd
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return errors.New("header must be given as \"Name: value\"")
+	}
+
+	h[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest_ForHost(t *testing.T) {
+	r := Request{
+		Headers: map[string]string{"X-Env": "prod", "Accept-Language": "en"},
+		Cookies: false,
+		Hosts: map[string]HostRequest{
+			"staging.go.test": {
+				Headers: map[string]string{"X-Env": "staging"},
+				Auth:    Auth{Username: "admin", Password: "secret", Token: ""},
+			},
+		},
+	}
+
+	assert.Equal(t, HostRequest{
+		Headers: map[string]string{"X-Env": "staging", "Accept-Language": "en"},
+		Auth:    Auth{Username: "admin", Password: "secret", Token: ""},
+	}, r.ForHost("staging.go.test:8080"))
+
+	assert.Equal(t, HostRequest{
+		Headers: map[string]string{"X-Env": "prod", "Accept-Language": "en"},
+		Auth:    Auth{Username: "", Password: "", Token: ""},
+	}, r.ForHost("go.test"))
+}
+
+func TestRequest_Resolve(t *testing.T) {
+	t.Setenv("CRAWLER_TEST_TOKEN", "t0ken")
+	t.Setenv("CRAWLER_TEST_KEY", "k3y")
+
+	r := Request{
+		Headers: map[string]string{"X-Api-Key": "${CRAWLER_TEST_KEY}", "X-Plain": "$1 ${"},
+		Cookies: true,
+		Hosts: map[string]HostRequest{
+			"api.go.test": {Headers: nil, Auth: Auth{Username: "", Password: "", Token: "${CRAWLER_TEST_TOKEN}"}},
+		},
+	}
+
+	resolved, err := r.Resolve()
+	assert.Nil(t, err)
+	assert.True(t, resolved.Cookies)
+	assert.Equal(t, map[string]string{"X-Api-Key": "k3y", "X-Plain": "$1 ${"}, resolved.Headers)
+	assert.Equal(t, "t0ken", resolved.Hosts["api.go.test"].Auth.Token)
+	assert.Equal(t, "${CRAWLER_TEST_TOKEN}", r.Hosts["api.go.test"].Auth.Token)
+
+	r.Hosts["api.go.test"] = HostRequest{Headers: nil, Auth: Auth{Username: "", Password: "${CRAWLER_TEST_UNSET}", Token: ""}}
+
+	_, err = r.Resolve()
+	assert.EqualError(t, err, "environment variable CRAWLER_TEST_UNSET is not set")
+}
+
+func TestRequest_snapshot(t *testing.T) {
+	r := Request{
+		Headers: map[string]string{"Authorization": "Bearer s3cret", "X-Api-Key": "${CRAWLER_TEST_KEY}"},
+		Cookies: true,
+		Hosts: map[string]HostRequest{
+			"api.go.test": {
+				Headers: map[string]string{"Cookie": "session=s3cret"},
+				Auth:    Auth{Username: "admin", Password: "${CRAWLER_TEST_PASSWORD}", Token: "s3cret"},
+			},
+		},
+	}
+
+	assert.Equal(t, Request{
+		Headers: map[string]string{"X-Api-Key": "${CRAWLER_TEST_KEY}"},
+		Cookies: true,
+		Hosts: map[string]HostRequest{
+			"api.go.test": {
+				Headers: map[string]string{},
+				Auth:    Auth{Username: "", Password: "${CRAWLER_TEST_PASSWORD}", Token: ""},
+			},
+		},
+	}, r.snapshot())
+	assert.Equal(t, "Bearer s3cret", r.Headers["Authorization"], "the settings are not changed")
+}
+
+func Test_headerFlag(t *testing.T) {
+	headers := make(headerFlag)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(headers, "H", "")
+
+	assert.Nil(t, fs.Parse([]string{"-H", "X-Env: staging", "-H", "Authorization:Bearer a:b"}))
+	assert.Equal(t, headerFlag{"X-Env": "staging", "Authorization": "Bearer a:b"}, headers)
+	assert.Error(t, headers.Set("no colon"))
+}
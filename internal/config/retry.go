@@ -0,0 +1,57 @@
+package config
+
+import "time"
+
+const (
+	DefaultMaxRetries       = 2
+	DefaultInitialBackoff   = 500 * time.Millisecond
+	DefaultMaxBackoff       = 30 * time.Second
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// Retry - rules of repeating requests failed with a transient error and of pausing failing hosts
+type Retry struct {
+	// MaxRetries - number of repeated requests after the first one, negative value disables retries
+	MaxRetries int `yaml:"max_retries"`
+	// InitialBackoff - upper bound of the random pause before the first retry, doubled for every next retry
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// MaxBackoff - upper bound of any pause before a retry including the Retry-After header value
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// BreakerThreshold - consecutive transient failures pausing the host, negative value disables the breaker
+	BreakerThreshold int `yaml:"breaker_threshold"`
+	// BreakerCooldown - pause of a failing host
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown"`
+}
+
+func DefaultRetry() Retry {
+	return Retry{
+		MaxRetries:       DefaultMaxRetries,
+		InitialBackoff:   DefaultInitialBackoff,
+		MaxBackoff:       DefaultMaxBackoff,
+		BreakerThreshold: DefaultBreakerThreshold,
+		BreakerCooldown:  DefaultBreakerCooldown,
+	}
+}
+
+func (r *Retry) merge(o Retry) {
+	if o.MaxRetries != 0 {
+		r.MaxRetries = o.MaxRetries
+	}
+
+	if o.InitialBackoff != 0 {
+		r.InitialBackoff = o.InitialBackoff
+	}
+
+	if o.MaxBackoff != 0 {
+		r.MaxBackoff = o.MaxBackoff
+	}
+
+	if o.BreakerThreshold != 0 {
+		r.BreakerThreshold = o.BreakerThreshold
+	}
+
+	if o.BreakerCooldown != 0 {
+		r.BreakerCooldown = o.BreakerCooldown
+	}
+}
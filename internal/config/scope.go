@@ -0,0 +1,59 @@
+package config
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// Scope - rules deciding which discovered links enter the frontier
+type Scope struct {
+	// SameHost - follows only links to the seed host
+	SameHost bool `yaml:"same_host"`
+	// AllowSubdomains - also follows links to subdomains of the seed host
+	AllowSubdomains bool `yaml:"allow_subdomains"`
+	// AllowedDomains - follows links to these domains and their subdomains besides the seed host
+	AllowedDomains []string `yaml:"allowed_domains"`
+	// DeniedDomains - never follows links to these domains and their subdomains
+	DeniedDomains []string `yaml:"denied_domains"`
+	// Include - follows only urls matching at least one of the regular expressions
+	Include []string `yaml:"include"`
+	// Exclude - never follows urls matching any of the regular expressions
+	Exclude []string `yaml:"exclude"`
+	// ReportOutOfScope - outputs links which are not followed with the "out of scope" reason
+	ReportOutOfScope bool `yaml:"report_out_of_scope"`
+}
+
+// RestrictsHosts - reports whether the scope limits the crawl to some hosts
+func (s Scope) RestrictsHosts() bool {
+	return s.SameHost || s.AllowSubdomains || len(s.AllowedDomains) > 0
+}
+
+func (s *Scope) merge(o Scope) {
+	if o.SameHost {
+		s.SameHost = o.SameHost
+	}
+
+	if o.AllowSubdomains {
+		s.AllowSubdomains = o.AllowSubdomains
+	}
+
+	if o.ReportOutOfScope {
+		s.ReportOutOfScope = o.ReportOutOfScope
+	}
+
+	s.AllowedDomains = append(s.AllowedDomains, o.AllowedDomains...)
+	s.DeniedDomains = append(s.DeniedDomains, o.DeniedDomains...)
+	s.Include = append(s.Include, o.Include...)
+	s.Exclude = append(s.Exclude, o.Exclude...)
+}
+
+func (s Scope) validate() error {
+	for _, expr := range append(append([]string{}, s.Include...), s.Exclude...) {
+		if _, err := regexp.Compile(expr); err != nil {
+			return errors.Wrap(err, "wrong scope regular expression ("+expr+")")
+		}
+	}
+
+	return nil
+}
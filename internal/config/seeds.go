@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// StdinSeeds - seeds file name reading the seeds from the standard input
+const StdinSeeds = "-"
+
+// Seed - url the crawl starts from with its own depth limit and scope
+type Seed struct {
+	URL string `yaml:"url"`
+	// MaxDepth - depth limit of the pages found from the seed, max_depth is used if it is not set
+	MaxDepth *uint64 `yaml:"max_depth"`
+	// Scope - scope of the pages found from the seed replacing the global one
+	Scope *Scope `yaml:"scope"`
+}
+
+// UnmarshalYAML - accepts a plain url as well as a mapping
+func (s *Seed) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		s.URL = value.Value
+
+		return nil
+	}
+
+	type plain Seed
+
+	return value.Decode((*plain)(s))
+}
+
+func (s Seed) validate() error {
+	if _, err := url.ParseRequestURI(s.URL); err != nil {
+		return errors.New("wrong seed url (" + s.URL + "); error: " + err.Error())
+	}
+
+	if s.Scope != nil {
+		return s.Scope.validate()
+	}
+
+	return nil
+}
+
+// urlFlag - collects repeated -u flags, the first one is the url and the rest are seeds
+type urlFlag []string
+
+func (u *urlFlag) String() string {
+	return strings.Join(*u, ",")
+}
+
+func (u *urlFlag) Set(value string) error {
+	*u = append(*u, value)
+
+	return nil
+}
+
+// loadSeedsFile - appends the seeds of the seeds file or the standard input to the configured ones
+func (c *configuration) loadSeedsFile() (err error) {
+	if c.seedsFile == "" {
+		return nil
+	}
+
+	var r io.Reader = os.Stdin
+
+	if c.seedsFile != StdinSeeds {
+		f, oErr := os.Open(c.seedsFile)
+		if oErr != nil {
+			return errors.Wrap(oErr, "seeds file opening")
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	seeds, err := readSeeds(r)
+	if err != nil {
+		return err
+	}
+
+	c.seeds = append(c.seeds, seeds...)
+
+	return nil
+}
+
+// readSeeds - returns a seed for every line, empty lines and lines starting with # are skipped
+func readSeeds(r io.Reader) (seeds []Seed, err error) {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		seeds = append(seeds, Seed{URL: line, MaxDepth: nil, Scope: nil})
+	}
+
+	return seeds, errors.Wrap(scanner.Err(), "seeds reading")
+}
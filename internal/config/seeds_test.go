@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSeed_UnmarshalYAML(t *testing.T) {
+	var fc fileConfiguration
+
+	content := `
+seeds:
+  - https://ya.ru
+  - url: https://go.dev/doc/
+    max_depth: 0
+    scope:
+      include: ['^https://go\.dev/doc/']
+`
+	assert.Nil(t, yaml.Unmarshal([]byte(content), &fc))
+	assert.Len(t, fc.Seeds, 2)
+	assert.Equal(t, Seed{URL: "https://ya.ru", MaxDepth: nil, Scope: nil}, fc.Seeds[0])
+	assert.Equal(t, "https://go.dev/doc/", fc.Seeds[1].URL)
+	assert.Equal(t, uint64(0), *fc.Seeds[1].MaxDepth)
+	assert.Equal(t, []string{`^https://go\.dev/doc/`}, fc.Seeds[1].Scope.Include)
+}
+
+func TestSeed_validate(t *testing.T) {
+	wrongScope := &Scope{Include: []string{"("}} //nolint:exhaustivestruct
+
+	assert.Nil(t, Seed{URL: "https://ya.ru"}.validate())                      //nolint:exhaustivestruct
+	assert.Error(t, Seed{URL: "ya.ru"}.validate())                            //nolint:exhaustivestruct
+	assert.Error(t, Seed{URL: "https://ya.ru", Scope: wrongScope}.validate()) //nolint:exhaustivestruct
+}
+
+func TestConfiguration_Seeds(t *testing.T) {
+	depth := uint64(1)
+	c := configuration{ //nolint:exhaustivestruct
+		url: "https://ya.ru",
+		seeds: []Seed{
+			{URL: "https://go.dev", MaxDepth: nil, Scope: nil},
+			{URL: "https://ya.ru", MaxDepth: &depth, Scope: nil},
+			{URL: "https://go.dev", MaxDepth: &depth, Scope: nil},
+		},
+	}
+
+	assert.Equal(t, []Seed{
+		{URL: "https://ya.ru", MaxDepth: &depth, Scope: nil},
+		{URL: "https://go.dev", MaxDepth: nil, Scope: nil},
+	}, c.Seeds())
+	assert.Empty(t, (&configuration{}).Seeds()) //nolint:exhaustivestruct
+}
+
+func Test_readSeeds(t *testing.T) {
+	seeds, err := readSeeds(strings.NewReader("https://ya.ru\n\n# comment\n  https://go.dev  \n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []Seed{
+		{URL: "https://ya.ru", MaxDepth: nil, Scope: nil},
+		{URL: "https://go.dev", MaxDepth: nil, Scope: nil},
+	}, seeds)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Sitemaps - seeding of the crawl with the urls of sitemaps and feeds
+type Sitemaps struct {
+	// Discover - reads the sitemaps listed in robots.txt of the seed host and its /sitemap.xml
+	Discover bool `yaml:"discover"`
+	// URLs - sitemaps, sitemap indexes, rss or atom feeds read for the seeds, relative urls are resolved against a seed
+	URLs []string `yaml:"urls"`
+}
+
+// Enabled - reports whether any sitemaps are read
+func (s Sitemaps) Enabled() bool {
+	return s.Discover || len(s.URLs) > 0
+}
+
+func (s *Sitemaps) merge(o Sitemaps) {
+	if o.Discover {
+		s.Discover = o.Discover
+	}
+
+	if len(o.URLs) > 0 {
+		s.URLs = o.URLs
+	}
+}
+
+func (s Sitemaps) validate() error {
+	for _, u := range s.URLs {
+		if _, err := url.Parse(u); err != nil {
+			return errors.Wrap(err, "wrong sitemap url ("+u+")")
+		}
+	}
+
+	return nil
+}
+
+// SitemapOutput - sitemap generated from the indexable pages of the crawl
+type SitemapOutput struct {
+	// Path - file the sitemap is written to, a sitemap index when the pages do not fit into one sitemap
+	Path string `yaml:"path"`
+	// Gzip - compresses the sitemaps, .gz is appended to the file names
+	Gzip bool `yaml:"gzip"`
+	// BaseURL - url the sitemaps are published at, referenced by the sitemap index, the root of the first page by default
+	BaseURL string `yaml:"base_url"`
+}
+
+// Enabled - reports whether the sitemap is generated
+func (s SitemapOutput) Enabled() bool {
+	return s.Path != ""
+}
+
+func (s *SitemapOutput) merge(o SitemapOutput) {
+	if o.Path != "" {
+		s.Path = o.Path
+	}
+
+	if o.Gzip {
+		s.Gzip = o.Gzip
+	}
+
+	if o.BaseURL != "" {
+		s.BaseURL = o.BaseURL
+	}
+}
+
+func (s SitemapOutput) validate() error {
+	if s.BaseURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(s.BaseURL)
+	if err != nil {
+		return errors.Wrap(err, "wrong sitemap base url ("+s.BaseURL+")")
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("wrong sitemap base url (" + s.BaseURL + "), an absolute http url is expected")
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSitemapOutput_validate(t *testing.T) {
+	assert.Nil(t, SitemapOutput{Path: "sitemap.xml"}.validate())                                       //nolint:exhaustivestruct
+	assert.Nil(t, SitemapOutput{Path: "sitemap.xml", BaseURL: "https://go.test/sitemaps/"}.validate()) //nolint:exhaustivestruct
+	assert.Error(t, SitemapOutput{Path: "sitemap.xml", BaseURL: "/sitemaps/"}.validate())              //nolint:exhaustivestruct
+	assert.Error(t, SitemapOutput{Path: "sitemap.xml", BaseURL: "ftp://go.test/"}.validate())          //nolint:exhaustivestruct
+	assert.False(t, SitemapOutput{}.Enabled())                                                         //nolint:exhaustivestruct
+	assert.True(t, SitemapOutput{Path: "sitemap.xml"}.Enabled())                                       //nolint:exhaustivestruct
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AllHosts - client certificate key matching every host without its own certificate
+const AllHosts = "*"
+
+// ClientCert - pem files of a client certificate and its private key
+type ClientCert struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+}
+
+// TLS - verification of the server certificates and client certificates of the requests
+type TLS struct {
+	// CAFiles - pem files of certificate authorities trusted in addition to the system ones
+	CAFiles []string `yaml:"ca_files"`
+	// InsecureSkipVerify - accepts any server certificate, for testing only
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+	// ClientCerts - client certificates by host name, "*" is sent to the other hosts
+	ClientCerts map[string]ClientCert `yaml:"client_certs"`
+	// ExpiryDays - reports the server certificates expiring within the days when the crawl is finished, zero disables
+	ExpiryDays int `yaml:"expiry_days"`
+}
+
+func (t *TLS) merge(o TLS) {
+	if len(o.CAFiles) > 0 {
+		t.CAFiles = o.CAFiles
+	}
+
+	if o.InsecureSkipVerify {
+		t.InsecureSkipVerify = o.InsecureSkipVerify
+	}
+
+	for host, cert := range o.ClientCerts {
+		if t.ClientCerts == nil {
+			t.ClientCerts = make(map[string]ClientCert, len(o.ClientCerts))
+		}
+
+		t.ClientCerts[strings.ToLower(host)] = cert
+	}
+
+	if o.ExpiryDays != 0 {
+		t.ExpiryDays = o.ExpiryDays
+	}
+}
+
+func (t TLS) validate() error {
+	if t.ExpiryDays < 0 {
+		return errors.New("certificate expiry days must not be negative")
+	}
+
+	for host, cert := range t.ClientCerts {
+		if cert.Cert == "" || cert.Key == "" {
+			return errors.New("client certificate of " + host + " must have cert and key files")
+		}
+	}
+
+	return nil
+}
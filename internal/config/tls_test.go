@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLS_validate(t *testing.T) {
+	valid := map[string]ClientCert{"*": {Cert: "client.pem", Key: "client.key"}}
+	noKey := map[string]ClientCert{"go.test": {Cert: "client.pem", Key: ""}}
+
+	assert.Nil(t, TLS{}.validate())                     //nolint:exhaustivestruct
+	assert.Nil(t, TLS{ExpiryDays: 30}.validate())       //nolint:exhaustivestruct
+	assert.Error(t, TLS{ExpiryDays: -1}.validate())     //nolint:exhaustivestruct
+	assert.Nil(t, TLS{ClientCerts: valid}.validate())   //nolint:exhaustivestruct
+	assert.Error(t, TLS{ClientCerts: noKey}.validate()) //nolint:exhaustivestruct
+}
+
+func TestTLS_merge(t *testing.T) {
+	tls := TLS{CAFiles: []string{"ca.pem"}, ExpiryDays: 30} //nolint:exhaustivestruct
+	tls.merge(TLS{                                          //nolint:exhaustivestruct
+		InsecureSkipVerify: true,
+		ClientCerts:        map[string]ClientCert{"Go.Test": {Cert: "client.pem", Key: "client.key"}},
+	})
+
+	assert.Equal(t, []string{"ca.pem"}, tls.CAFiles)
+	assert.True(t, tls.InsecureSkipVerify)
+	assert.Equal(t, ClientCert{Cert: "client.pem", Key: "client.key"}, tls.ClientCerts["go.test"])
+	assert.Equal(t, 30, tls.ExpiryDays)
+}
@@ -0,0 +1,73 @@
+package config
+
+import "time"
+
+const (
+	DefaultMaxIdleConns          = 100
+	DefaultMaxIdleConnsPerHost   = 10
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultDialTimeout           = 10 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 0
+)
+
+// Transport - connection settings of the http client shared by all requests of the crawl,
+// timeouts are applied separately from the overall request timeout
+type Transport struct {
+	// MaxIdleConns - idle keep-alive connections kept open across all hosts
+	MaxIdleConns int `yaml:"max_idle_conns"`
+	// MaxIdleConnsPerHost - idle keep-alive connections kept open to a single host
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host"`
+	// IdleConnTimeout - time an idle connection is kept open
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout"`
+	// DisableHTTP2 - uses HTTP/1.1 only
+	DisableHTTP2 bool `yaml:"disable_http2"`
+	// DialTimeout - limit of establishing a tcp connection
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+	// TLSHandshakeTimeout - limit of the tls handshake
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout"`
+	// ResponseHeaderTimeout - limit of waiting for the response headers after the request is sent, zero means no limit
+	ResponseHeaderTimeout time.Duration `yaml:"response_header_timeout"`
+}
+
+func DefaultTransport() Transport {
+	return Transport{
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		DisableHTTP2:          false,
+		DialTimeout:           DefaultDialTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+	}
+}
+
+func (t *Transport) merge(o Transport) {
+	if o.MaxIdleConns != 0 {
+		t.MaxIdleConns = o.MaxIdleConns
+	}
+
+	if o.MaxIdleConnsPerHost != 0 {
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+
+	if o.IdleConnTimeout != 0 {
+		t.IdleConnTimeout = o.IdleConnTimeout
+	}
+
+	if o.DisableHTTP2 {
+		t.DisableHTTP2 = o.DisableHTTP2
+	}
+
+	if o.DialTimeout != 0 {
+		t.DialTimeout = o.DialTimeout
+	}
+
+	if o.TLSHandshakeTimeout != 0 {
+		t.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	}
+
+	if o.ResponseHeaderTimeout != 0 {
+		t.ResponseHeaderTimeout = o.ResponseHeaderTimeout
+	}
+}
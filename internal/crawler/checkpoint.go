@@ -0,0 +1,178 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/vfunin/crawler/internal/checkpoint"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/scope"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// restore - loads the visited set and the pending frontier of the previous run
+func (c *crawler) restore(ctx context.Context, errCh chan<- error) {
+	if c.checkpoint == nil {
+		return
+	}
+
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+
+	visited, pending, err := c.checkpoint.Restore()
+	if err != nil {
+		c.sendErr(ctx, errCh, err)
+
+		return
+	}
+
+	c.mu.Lock()
+	for _, url := range visited {
+		c.visited[url] = struct{}{}
+	}
+	c.mu.Unlock()
+
+	scopes := make(map[string]scope.Scope)
+	dropped := 0
+
+	for _, p := range pending {
+		// external links of a link check run are not crawled when it is resumed without the link check
+		if p.External && c.linkCheck == nil {
+			c.checkpointDone(ctx, p.URL)
+			dropped++
+
+			continue
+		}
+
+		s, ok := scopes[p.Seed]
+		if !ok {
+			if s, err = c.seedScope(p.Seed); err != nil {
+				c.sendErr(ctx, errCh, err)
+
+				continue
+			}
+
+			scopes[p.Seed] = s
+		}
+
+		c.IncCnt()
+		c.frontier.push(task{
+			url:       p.URL,
+			depth:     p.Depth,
+			withPanic: p.WithPanic,
+			seed:      p.Seed,
+			scope:     s,
+			referrer:  p.Referrer,
+			external:  p.External,
+			leaf:      p.Leaf,
+			kind:      p.Kind,
+			source:    p.Source,
+		})
+	}
+
+	log.Info().Msgf("crawl restored: %d visited, %d pending urls, %d external urls dropped",
+		len(visited), len(pending)-dropped, dropped)
+
+	c.replay(ctx, errCh)
+}
+
+// replay - outputs the results of the previous run as restored ones, so the reports cover the whole crawl
+func (c *crawler) replay(ctx context.Context, errCh chan<- error) {
+	results, err := c.checkpoint.Results()
+	if err != nil {
+		c.sendErr(ctx, errCh, err)
+
+		return
+	}
+
+	for _, result := range results {
+		var r Result
+
+		if err = json.Unmarshal(result, &r); err != nil {
+			c.sendErr(ctx, errCh, errors.Wrap(err, "checkpoint result decoding"))
+
+			continue
+		}
+
+		r.Restored = true
+
+		select {
+		case c.result <- r:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *crawler) checkpointPush(ctx context.Context, t task) {
+	if c.checkpoint == nil {
+		return
+	}
+
+	err := c.checkpoint.Push(checkpoint.Task{
+		URL:       t.url,
+		Depth:     t.depth,
+		WithPanic: t.withPanic,
+		Seed:      t.seed,
+		Referrer:  t.referrer,
+		External:  t.external,
+		Leaf:      t.leaf,
+		Kind:      t.kind,
+		Source:    t.source,
+		Seq:       0,
+	})
+	logCheckpointErr(ctx, err)
+}
+
+func (c *crawler) checkpointVisit(ctx context.Context, url string) {
+	if c.checkpoint == nil {
+		return
+	}
+
+	logCheckpointErr(ctx, c.checkpoint.Visit(url))
+}
+
+func (c *crawler) checkpointDone(ctx context.Context, url string) {
+	if c.checkpoint == nil {
+		return
+	}
+
+	logCheckpointErr(ctx, c.checkpoint.Done(url))
+}
+
+func (c *crawler) checkpointEmit(ctx context.Context, r Result) {
+	if c.checkpoint == nil {
+		return
+	}
+
+	result, err := json.Marshal(r)
+	if err != nil {
+		logCheckpointErr(ctx, errors.Wrap(err, "checkpoint result encoding"))
+
+		return
+	}
+
+	logCheckpointErr(ctx, c.checkpoint.Emit(r.URL, result))
+}
+
+// emitted - reports whether the result of url has been output by the previous run
+func (c *crawler) emitted(ctx context.Context, url string) bool {
+	if c.checkpoint == nil {
+		return false
+	}
+
+	emitted, err := c.checkpoint.Emitted(url)
+	logCheckpointErr(ctx, err)
+
+	return emitted
+}
+
+func logCheckpointErr(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+	log.Err(err).Msg("checkpoint error")
+}
@@ -0,0 +1,225 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/checkpoint"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/sitemap"
+)
+
+func TestCrawlResume(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<title>%s</title><a href="/a">a</a><a href="/b">b</a>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	seed := server.URL + "/"
+
+	store, err := checkpoint.Open(dir, false)
+	assert.Nil(t, err)
+
+	// the previous run has output the seed and has been interrupted before /a and /b were processed
+	assert.Nil(t, store.Push(checkpoint.Task{URL: seed, Depth: 0, WithPanic: false, Seed: seed, Referrer: "", Seq: 0}))
+	assert.Nil(t, store.Emit(seed, []byte(`{"URL":"`+seed+`","StatusCode":200}`)))
+	assert.Nil(t, store.Done(seed))
+	assert.Nil(t, store.Push(checkpoint.Task{URL: server.URL + "/a", Depth: 1, WithPanic: false, Seed: seed, Referrer: "", Seq: 0}))
+	assert.Nil(t, store.Push(checkpoint.Task{URL: server.URL + "/b", Depth: 1, WithPanic: false, Seed: seed, Referrer: "", Seq: 0}))
+	assert.Nil(t, store.Emit(server.URL+"/a", nil))
+	assert.Nil(t, store.Close())
+
+	store, err = checkpoint.Open(dir, true)
+	assert.Nil(t, err)
+
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	c := New(1, 1, WithCheckpoint(store))
+	errCh := make(chan error, 10)
+
+	go c.Crawl(ctx, cancel, "", false, 0, errCh)
+
+	var results, restored []string
+
+	for res := range c.ResultCh() {
+		if res.Restored {
+			restored = append(restored, res.URL)
+
+			continue
+		}
+
+		results = append(results, res.URL)
+		res.Written()
+	}
+
+	assert.Equal(t, []string{server.URL + "/b"}, results)
+	assert.Equal(t, []string{seed}, restored, "the kept results of the previous run are output for the reports")
+
+	_, pending, err := store.Restore()
+	assert.Nil(t, err)
+	assert.Empty(t, pending)
+
+	emitted, err := store.Emitted(server.URL + "/b")
+	assert.Nil(t, err)
+	assert.True(t, emitted)
+}
+
+func TestCrawlEmitWritten(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<title>%s</title>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	store, err := checkpoint.Open(t.TempDir(), false)
+	assert.Nil(t, err)
+
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	c := New(1, 1, WithCheckpoint(store))
+	seed := server.URL + "/"
+
+	go c.Crawl(ctx, cancel, seed, false, 0, make(chan error, 10))
+
+	res := <-c.ResultCh()
+
+	emitted, err := store.Emitted(seed)
+	assert.Nil(t, err)
+	assert.False(t, emitted, "the result is not emitted until it is written")
+
+	res.Written()
+
+	_, ok := <-c.ResultCh()
+	assert.False(t, ok)
+
+	emitted, err = store.Emitted(seed)
+	assert.Nil(t, err)
+	assert.True(t, emitted)
+
+	results, err := store.Results()
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestCrawlResumeDropsExternal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<title>%s</title>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	seed := server.URL + "/"
+
+	store, err := checkpoint.Open(dir, false)
+	assert.Nil(t, err)
+
+	// the previous run has checked links and has been interrupted before the external link was checked
+	assert.Nil(t, store.Push(checkpoint.Task{URL: seed, Depth: 0, WithPanic: false, Seed: seed, Referrer: "", Seq: 0}))
+	assert.Nil(t, store.Push(checkpoint.Task{ //nolint:exhaustivestruct
+		URL: "http://external.test/", Depth: 1, Seed: seed, Referrer: seed, External: true,
+	}))
+	assert.Nil(t, store.Close())
+
+	store, err = checkpoint.Open(dir, true)
+	assert.Nil(t, err)
+
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	c := New(1, 1, WithCheckpoint(store))
+	errCh := make(chan error, 10)
+
+	go c.Crawl(ctx, cancel, "", false, 0, errCh)
+
+	var results []string
+
+	for res := range c.ResultCh() {
+		results = append(results, res.URL)
+		res.Written()
+	}
+
+	assert.Equal(t, []string{seed}, results)
+
+	_, pending, err := store.Restore()
+	assert.Nil(t, err)
+	assert.Empty(t, pending)
+}
+
+// slowStore - delays saving the push of an url to the frontier
+type slowStore struct {
+	checkpoint.Store
+	url   string
+	delay time.Duration
+}
+
+func (s slowStore) Push(t checkpoint.Task) error {
+	if t.URL == s.url {
+		time.Sleep(s.delay)
+	}
+
+	return s.Store.Push(t)
+}
+
+// seedSitemaps - lists the entries of the sitemaps of every seed
+type seedSitemaps map[string][]sitemap.Entry
+
+func (s seedSitemaps) Entries(_ context.Context, seed string) ([]sitemap.Entry, error) {
+	return s[seed], nil
+}
+
+func TestCrawlSeedSitemapsCounted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<title>%s</title>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	store, err := checkpoint.Open(t.TempDir(), false)
+	assert.Nil(t, err)
+
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	second := server.URL + "/second"
+	c := New(1, 1,
+		// the second seed is crawled while its push is being saved
+		WithCheckpoint(slowStore{Store: store, url: second, delay: 100 * time.Millisecond}),
+		WithSitemaps(seedSitemaps{second: {{URL: server.URL + "/orphan", Source: server.URL + "/sitemap.xml"}}}),
+	)
+	errCh := make(chan error, 10)
+
+	c.IncCnt()
+
+	go c.Crawl(ctx, cancel, server.URL+"/", false, 0, errCh)
+
+	first := <-c.ResultCh()
+	first.Written()
+
+	results := []string{strings.TrimPrefix(first.URL, server.URL)}
+
+	go c.Crawl(ctx, cancel, second, false, 0, errCh)
+
+	for res := range c.ResultCh() {
+		results = append(results, strings.TrimPrefix(res.URL, server.URL))
+		res.Written()
+	}
+
+	assert.Empty(t, errCh)
+	assert.ElementsMatch(t, []string{"/", "/second", "/orphan"}, results, "the sitemap urls of a seed are crawled")
+}
@@ -2,21 +2,100 @@ package crawler
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/vfunin/crawler/internal/canonical"
+	"github.com/vfunin/crawler/internal/checkpoint"
 	"github.com/vfunin/crawler/internal/config"
 	"github.com/vfunin/crawler/internal/fetcher"
+	"github.com/vfunin/crawler/internal/parser"
+	"github.com/vfunin/crawler/internal/politeness"
+	"github.com/vfunin/crawler/internal/retry"
+	"github.com/vfunin/crawler/internal/robots"
+	"github.com/vfunin/crawler/internal/scope"
+	"github.com/vfunin/crawler/internal/sitemap"
 
 	"github.com/pkg/errors"
 
 	"github.com/rs/zerolog"
 )
 
+const (
+	SkipDisallowedByRobots = "disallowed by robots"
+	SkipOutOfScope         = "out of scope"
+	// SkipNotFollowed - link of a kind which is reported but not crawled
+	SkipNotFollowed = "not followed"
+)
+
+// Indexability of a fetched url
+const (
+	// IndexabilityIndexable - successful response without noindex directives, canonical to itself or without a canonical url
+	IndexabilityIndexable = "indexable"
+	// IndexabilityNoIndex - successful response with a noindex meta robots tag or X-Robots-Tag header
+	IndexabilityNoIndex = "noindex"
+	// IndexabilityCanonicalized - successful page whose canonical url is another page
+	IndexabilityCanonicalized = "canonicalized"
+)
+
 type Result struct {
 	URL   string
 	Title string
+	// Skipped - reason why the url has not been fetched, empty for fetched urls
+	Skipped    string
+	StatusCode int
+	// Error - kind of the fetch failure, set only in the link check mode
+	Error string
+	// Retries - number of repeated requests after transient failures
+	Retries int
+	// FinalURL - url of the document after redirects
+	FinalURL string
+	// Redirects - urls redirected from, in order, without the final url
+	Redirects     []string
+	ContentType   string
+	ContentLength int64
+	// Truncated - the body exceeds the size limit and only its beginning is parsed
+	Truncated bool
+	// Charset - encoding of the parsed page, e.g. windows-1251
+	Charset string
+	// Canonical - canonicalized url of the <link rel="canonical"> of the page, empty if it has none
+	Canonical string
+	// Indexability - whether search engines may index the url, empty for urls which are not fetched or are not successful
+	Indexability string
+	ResponseTime time.Duration
+	Depth        uint64
+	// Referrer - url of the page the url has been found on, empty for seeds
+	Referrer string
+	// Kind - kind of the link the url has been found as, e.g. navigation or asset, empty for seeds
+	Kind string
+	// Source - url of the sitemap or the feed the url has been found in first, empty for seeds and links
+	Source string
+	// External - the url is out of the scope or of a reported kind and is only checked in the link check mode
+	External bool
+	// CertIssuer - issuer of the server certificate, empty for plain http
+	CertIssuer string
+	// CertExpires - expiry of the server certificate, zero for plain http
+	CertExpires time.Time
+	// LastModified - time of the Last-Modified header, zero if the server has not sent it
+	LastModified time.Time
+	// Links - canonical links of the page with their anchor text
+	Links []parser.Link
+	// Restored - result output by the previous run of a resumed crawl, only the reports are rebuilt with it
+	Restored bool
+	// written - closed when the result is written to the output, set for crawls with a checkpoint
+	written chan struct{}
+}
+
+// Written - tells the crawler the result has been written to the output, so it is not output again
+// when the crawl is resumed
+func (r Result) Written() {
+	if r.written != nil {
+		close(r.written)
+	}
 }
 
 type Crawler interface {
@@ -27,30 +106,214 @@ type Crawler interface {
 	GetCnt() int64
 	MaxDepth() uint64
 	ResultCh() chan Result
+	Done() <-chan struct{}
+}
+
+type Option func(c *crawler)
+
+// WithWorkers - sets the number of workers processing the frontier concurrently
+func WithWorkers(workers int) Option {
+	return func(c *crawler) {
+		if workers > 0 {
+			c.workers = workers
+		}
+	}
+}
+
+// WithPoliteness - throttles requests to every host according to p
+func WithPoliteness(p politeness.Politeness) Option {
+	return func(c *crawler) {
+		c.politeness = p
+	}
+}
+
+// WithRobots - skips urls disallowed by robots.txt
+func WithRobots(r robots.Robots) Option {
+	return func(c *crawler) {
+		c.robots = r
+	}
+}
+
+// WithScope - follows only links in the scope of the seed url, out of scope links are reported if configured
+func WithScope(cfg config.Scope) Option {
+	return func(c *crawler) {
+		c.scope = &cfg
+	}
+}
+
+// WithCanonicalizer - canonicalizes urls before the visited check and output
+func WithCanonicalizer(cn canonical.Canonicalizer) Option {
+	return func(c *crawler) {
+		c.canonicalizer = cn
+	}
+}
+
+// WithSeeds - applies the depth limits and the scopes of the seeds to the pages found from them
+func WithSeeds(seeds []config.Seed) Option {
+	return func(c *crawler) {
+		c.seeds = seeds
+	}
+}
+
+// WithLinkCheck - outputs fetch failures as results and checks links out of the scope if configured,
+// external links are fetched once without robots.txt rules and are not crawled further
+func WithLinkCheck(cfg config.LinkCheck) Option {
+	return func(c *crawler) {
+		c.linkCheck = &cfg
+	}
+}
+
+// WithLinks - crawls the links of the kinds to follow and outputs the links of the kinds to report without crawling them,
+// in the link check mode reported links are checked once
+func WithLinks(cfg config.Links) Option {
+	return func(c *crawler) {
+		c.follow = cfg.Follow
+		c.report = cfg.Report
+	}
+}
+
+// WithDirectives - honors nofollow links, robots directives and canonical urls of the pages
+// unless they are ignored by cfg
+func WithDirectives(cfg config.Directives) Option {
+	return func(c *crawler) {
+		c.directives = cfg
+	}
+}
+
+// WithSitemaps - seeds the crawl with the urls of the sitemaps and the feeds of every seed
+func WithSitemaps(s sitemap.Sitemaps) Option {
+	return func(c *crawler) {
+		c.sitemaps = s
+	}
+}
+
+// WithFetcher - fetches all urls of the crawl with f instead of the default fetcher
+func WithFetcher(f fetcher.Fetcher) Option {
+	return func(c *crawler) {
+		c.fetcher = f
+	}
+}
+
+// WithRetry - repeats requests failed with a transient error according to p
+func WithRetry(p retry.Policy) Option {
+	return func(c *crawler) {
+		c.retry = p
+	}
+}
+
+// WithBreaker - pauses hosts failing repeatedly with transient errors
+func WithBreaker(b retry.Breaker) Option {
+	return func(c *crawler) {
+		c.breaker = b
+	}
+}
+
+// WithCheckpoint - persists the frontier, the visited set and the emitted results to store
+// and restores them on the first Crawl call
+func WithCheckpoint(store checkpoint.Store) Option {
+	return func(c *crawler) {
+		c.checkpoint = store
+	}
 }
 
 type crawler struct {
-	mu                sync.RWMutex
-	result            chan Result
-	visited           map[string]struct{}
-	maxDepth          uint64
-	connectionTimeout int
-	cnt               int64
+	mu            sync.RWMutex
+	result        chan Result
+	visited       map[string]struct{}
+	frontier      *frontier
+	fetcher       fetcher.Fetcher
+	retry         retry.Policy
+	breaker       retry.Breaker
+	politeness    politeness.Politeness
+	robots        robots.Robots
+	scope         *config.Scope
+	seeds         []config.Seed
+	seedsOnce     sync.Once
+	seedSettings  map[string]config.Seed
+	canonicalizer canonical.Canonicalizer
+	linkCheck     *config.LinkCheck
+	follow        []string
+	report        []string
+	directives    config.Directives
+	sitemaps      sitemap.Sitemaps
+	checkpoint    checkpoint.Store
+	leavesMu      sync.Mutex
+	leaves        map[string]task
+	leavesFull    bool
+	crawlCtx      context.Context
+	restoreOnce   sync.Once
+	startOnce     sync.Once
+	finishOnce    sync.Once
+	done          chan struct{}
+	wg            sync.WaitGroup
+	sitemapsWg    sync.WaitGroup
+	sitemapsMu    sync.Mutex
+	sitemapsDone  bool
+	maxDepth      uint64
+	baseDepth     uint64
+	workers       int
+	cnt           int64
 }
 
-func New(depth uint64, connectionTimeout int) Crawler {
-	return &crawler{
-		mu:                sync.RWMutex{},
-		result:            make(chan Result),
-		visited:           make(map[string]struct{}),
-		maxDepth:          depth,
-		connectionTimeout: connectionTimeout,
-		cnt:               1,
+func New(depth uint64, connectionTimeout int, opts ...Option) Crawler {
+	c := &crawler{
+		mu:            sync.RWMutex{},
+		result:        make(chan Result),
+		visited:       make(map[string]struct{}),
+		frontier:      newFrontier(),
+		fetcher:       nil,
+		retry:         nil,
+		breaker:       nil,
+		politeness:    nil,
+		robots:        nil,
+		scope:         nil,
+		seeds:         nil,
+		seedsOnce:     sync.Once{},
+		seedSettings:  nil,
+		canonicalizer: nil,
+		linkCheck:     nil,
+		follow:        config.DefaultLinks().Follow,
+		report:        config.DefaultLinks().Report,
+		directives:    config.Directives{IgnoreNoFollow: false, IgnoreCanonical: false},
+		sitemaps:      nil,
+		checkpoint:    nil,
+		leavesMu:      sync.Mutex{},
+		leaves:        make(map[string]task),
+		leavesFull:    false,
+		crawlCtx:      nil,
+		restoreOnce:   sync.Once{},
+		startOnce:     sync.Once{},
+		finishOnce:    sync.Once{},
+		done:          make(chan struct{}),
+		wg:            sync.WaitGroup{},
+		sitemapsWg:    sync.WaitGroup{},
+		sitemapsMu:    sync.Mutex{},
+		sitemapsDone:  false,
+		maxDepth:      depth,
+		baseDepth:     depth,
+		workers:       config.DefaultWorkers,
+		cnt:           1,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.fetcher == nil {
+		c.fetcher = fetcher.New(time.Duration(connectionTimeout) * time.Second)
 	}
+
+	return c
 }
 
+// IncMaxDepth - raises the depth limit and puts the links of the pages cut off at the old limit into the frontier
 func (c *crawler) IncMaxDepth(step uint64) {
-	atomic.AddUint64(&c.maxDepth, step)
+	c.leavesMu.Lock()
+	maxDepth := atomic.AddUint64(&c.maxDepth, step)
+	tasks := c.takeLeaves(maxDepth)
+	c.leavesMu.Unlock()
+
+	c.deepen(tasks)
 }
 
 func (c *crawler) IncCnt() {
@@ -58,7 +321,7 @@ func (c *crawler) IncCnt() {
 }
 
 func (c *crawler) DecCnt() {
-	atomic.AddInt64(&c.cnt, -1)
+	c.decCnt()
 }
 
 func (c *crawler) GetCnt() int64 {
@@ -69,85 +332,728 @@ func (c *crawler) MaxDepth() uint64 {
 	return atomic.LoadUint64(&c.maxDepth)
 }
 
-func (c *crawler) setVisited(url string) {
+// decCnt - decrements the pending task counter and closes the frontier when nothing is left to do
+func (c *crawler) decCnt() {
+	if atomic.AddInt64(&c.cnt, -1) == 0 {
+		c.frontier.close()
+	}
+}
+
+// ResultCh - returns the results channel, it is closed when the crawl is finished
+func (c *crawler) ResultCh() chan Result {
+	return c.result
+}
+
+// Done - returns a channel closed when the frontier is drained or the crawl is cancelled and all workers are stopped
+func (c *crawler) Done() <-chan struct{} {
+	return c.done
+}
+
+// tryVisit - marks url as visited and reports whether it has not been visited before
+func (c *crawler) tryVisit(url string) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if _, ok := c.visited[url]; ok {
+		return false
+	}
+
 	c.visited[url] = struct{}{}
-}
 
-func (c *crawler) ResultCh() chan Result {
-	return c.result
+	return true
 }
 
+// isVisited - reports whether url has been visited
 func (c *crawler) isVisited(url string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+
 	_, ok := c.visited[url]
 
 	return ok
 }
 
-func (c *crawler) canGoDeeper(depth uint64) bool {
-	return depth <= c.maxDepth
+// canGoDeeper - reports whether pages at depth may be crawled from seed
+func (c *crawler) canGoDeeper(seed string, depth uint64) bool {
+	return depth <= c.seedMaxDepth(seed, c.MaxDepth())
+}
+
+// seedMaxDepth - returns the depth limit of the pages found from seed: its own limit raised as much as maxDepth
+// has been raised since the start, maxDepth for seeds without one
+func (c *crawler) seedMaxDepth(seed string, maxDepth uint64) uint64 {
+	cfg, ok := c.seedSetting(seed)
+	if !ok || cfg.MaxDepth == nil {
+		return maxDepth
+	}
+
+	return *cfg.MaxDepth + maxDepth - c.baseDepth
+}
+
+// seedSetting - returns the settings of the canonical seed url, seeds which can not be canonicalized have none
+func (c *crawler) seedSetting(seed string) (config.Seed, bool) {
+	c.seedsOnce.Do(func() {
+		c.seedSettings = make(map[string]config.Seed, len(c.seeds))
+
+		for _, s := range c.seeds {
+			if u, err := c.canonicalize(s.URL); err == nil {
+				c.seedSettings[u] = s
+			}
+		}
+	})
+
+	s, ok := c.seedSettings[seed]
+
+	return s, ok
 }
 
 func (c *crawler) recoverAndCount(log zerolog.Logger) {
-	c.DecCnt()
+	defer c.decCnt()
 
 	if iErr := recover(); iErr != nil {
-		err := errors.New("url: " + iErr.(string))
+		err := errors.New("url: " + fmt.Sprint(iErr))
 
 		log.Err(err).Msg("panic during link parsing")
 	}
 }
 
-// Crawl - Scans the link for nested links and outputs them to the crawler.Result channel
+// Crawl - Puts the link into the frontier and processes it and all nested links with a pool of workers,
+// outputs them to the crawler.Result channel and returns when the frontier is drained or ctx is done,
+// the results channel is closed after that
 func (c *crawler) Crawl(ctx context.Context, cancel context.CancelFunc, url string, withPanic bool, depth uint64, errCh chan<- error) {
 	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
 
-	defer c.recoverAndCount(log)
+	c.restoreOnce.Do(func() {
+		c.leavesMu.Lock()
+		c.crawlCtx = ctx
+		c.leavesMu.Unlock()
+
+		c.restore(ctx, errCh)
+	})
+
+	c.pushSeed(ctx, url, withPanic, depth, errCh)
+
+	c.startOnce.Do(func() {
+		log.Debug().Msgf("starting %d crawl workers", c.workers)
+
+		for i := 0; i < c.workers; i++ {
+			c.wg.Add(1)
+
+			go c.work(ctx, errCh)
+		}
+	})
+
+	c.wg.Wait()
+
+	c.finishOnce.Do(func() {
+		// the sitemap goroutines still running after a cancellation may output results
+		c.sitemapsMu.Lock()
+		c.sitemapsDone = true
+		c.sitemapsMu.Unlock()
+		c.sitemapsWg.Wait()
+
+		log.Debug().Msg("crawl finished")
+		close(c.result)
+		close(c.done)
+	})
+}
+
+// pushSeed - puts the seed url into the frontier, the seed is already counted in cnt,
+// an empty seed only continues the restored crawl
+func (c *crawler) pushSeed(ctx context.Context, url string, withPanic bool, depth uint64, errCh chan<- error) {
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+
+	if url == "" {
+		c.decCnt()
+
+		return
+	}
 
-	if c.isVisited(url) {
+	url, err := c.canonicalize(url)
+	if err != nil {
+		c.sendErr(ctx, errCh, err)
+		c.decCnt()
+
+		return
+	}
+
+	s, err := c.seedScope(url)
+	if err != nil {
+		c.sendErr(ctx, errCh, err)
+		c.decCnt()
+
+		return
+	}
+
+	if !c.tryVisit(url) {
 		log.Debug().Msgf("url %s has been visited - skip", url)
+		c.decCnt()
+
+		return
+	}
+
+	seed := task{url: url, depth: depth, withPanic: withPanic, seed: url, scope: s, referrer: "", external: false, leaf: false, kind: "", source: ""}
+
+	// the sitemaps goroutine is counted before the seed is pushed, otherwise a worker finishing the seed could drop
+	// the counter to zero and close the frontier before the sitemap urls are pushed
+	sitemaps := c.sitemaps != nil && c.startSitemaps()
+	if sitemaps {
+		c.IncCnt()
+	}
+
+	if !c.push(ctx, seed) {
+		if sitemaps {
+			c.decCnt()
+			c.sitemapsWg.Done()
+		}
 
 		return
 	}
 
-	c.setVisited(url)
+	if sitemaps {
+		go c.pushSitemaps(ctx, seed, errCh)
+	}
+}
+
+// startSitemaps - adds a goroutine pushing the sitemap urls of a seed to sitemapsWg unless the crawl is finished,
+// the results channel is closed after they return
+func (c *crawler) startSitemaps() bool {
+	c.sitemapsMu.Lock()
+	defer c.sitemapsMu.Unlock()
+
+	if c.sitemapsDone {
+		return false
+	}
+
+	c.sitemapsWg.Add(1)
+
+	return true
+}
+
+// pushSitemaps - puts the urls of the sitemaps and the feeds of the seed into the frontier with the depth of the seed,
+// out of scope urls are handled like out of scope links
+func (c *crawler) pushSitemaps(ctx context.Context, seed task, errCh chan<- error) {
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+
+	defer c.sitemapsWg.Done()
+	defer c.decCnt()
+
+	entries, err := c.sitemaps.Entries(ctx, seed.url)
+	if err != nil {
+		c.sendErr(ctx, errCh, err)
+	}
+
+	log.Debug().Msgf("%d urls found in the sitemaps of %s", len(entries), seed.url)
+
+	for _, entry := range entries {
+		url, cErr := c.canonicalize(entry.URL)
+		if cErr != nil {
+			log.Debug().Err(cErr).Msgf("sitemap url %s is skipped", entry.URL)
+
+			continue
+		}
+
+		t := seed
+		t.url = url
+		t.withPanic = false
+		t.source = entry.Source
+
+		if t.scope != nil && !t.scope.Contains(url) {
+			c.outOfScope(ctx, t)
+
+			continue
+		}
+
+		if !c.tryVisit(url) {
+			continue
+		}
+
+		c.IncCnt()
+
+		if !c.push(ctx, t) {
+			return
+		}
+	}
+}
+
+// push - puts the visited task into the frontier and reports whether the frontier is still open
+func (c *crawler) push(ctx context.Context, t task) bool {
+	if !c.frontier.push(t) {
+		return false
+	}
+
+	c.checkpointPush(ctx, t)
+
+	return true
+}
+
+func (c *crawler) work(ctx context.Context, errCh chan<- error) {
+	defer c.wg.Done()
+
+	for {
+		t, ok := c.frontier.pop(ctx)
+		if !ok {
+			return
+		}
+
+		c.process(ctx, t, errCh)
+
+		if ctx.Err() == nil {
+			c.checkpointDone(ctx, t.url)
+		}
+	}
+}
+
+// process - fetches a single url, outputs the result and pushes nested links to the frontier
+func (c *crawler) process(ctx context.Context, t task, errCh chan<- error) {
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+
+	defer c.recoverAndCount(log)
 
 	select {
 	case <-ctx.Done():
 		return
 	default:
-		f := fetcher.New(time.Duration(c.connectionTimeout) * time.Second)
+		reason, err := c.skipReason(ctx, t)
+		if err != nil {
+			c.sendErr(ctx, errCh, err)
+
+			return
+		}
+
+		if reason != "" {
+			log.Debug().Msgf("url %s is skipped: %s", t.url, reason)
+			c.send(ctx, t.skipped(reason))
+
+			return
+		}
 
-		page, err := f.Fetch(ctx, url)
+		resp, retries, err := c.fetchWithRetries(ctx, t)
 		if err != nil {
-			errCh <- err
+			c.fetchFailed(ctx, t, retries, err, errCh)
 
 			return
 		}
 
-		c.result <- Result{
-			Title: page.Title(),
-			URL:   url,
+		r := t.result(resp)
+		r.Retries = retries
+		if resp.Page != nil && !t.external {
+			r.Links = c.anchors(ctx, resp.Page.Anchors())
 		}
 
-		if !c.canGoDeeper(depth + 1) {
-			log.Debug().Msgf("depth limit reached for url %s", url)
+		self := c.self(t, resp)
+		r.Canonical = c.pageCanonical(ctx, resp)
+		r.Indexability = indexability(resp, r.Canonical, self)
 
+		if !c.send(ctx, r) || t.external {
 			return
 		}
 
-		for _, link := range page.Links() {
+		if resp.Page == nil {
+			log.Debug().Msgf("url %s responded with status %d - links are not followed", t.url, resp.StatusCode)
+
+			return
+		}
+
+		links := c.links(ctx, t, c.followable(ctx, t, resp, r, self))
+
+		if c.checkLeaves(ctx, t, links) {
+			log.Debug().Msgf("depth limit reached for url %s - links are checked", t.url)
+
+			return
+		}
+
+		if c.keepLeaf(t, links) {
+			log.Debug().Msgf("depth limit reached for url %s", t.url)
+
+			return
+		}
+
+		for _, link := range links {
+			if !c.tryVisit(link.URL) {
+				continue
+			}
+
 			c.IncCnt()
+			c.push(ctx, t.child(link))
+		}
+
+		if t.withPanic {
+			panic(t.url)
+		}
+	}
+}
+
+// anchors - returns the page links with canonical urls, links which can not be canonicalized are skipped
+func (c *crawler) anchors(ctx context.Context, anchors []parser.Link) []parser.Link {
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+	links := make([]parser.Link, 0, len(anchors))
+
+	for _, anchor := range anchors {
+		link, err := c.canonicalize(anchor.URL)
+		if err != nil {
+			log.Debug().Err(err).Msgf("link %s is skipped", anchor.URL)
+
+			continue
+		}
+
+		links = append(links, parser.Link{URL: link, Text: anchor.Text, Kind: anchor.Kind, NoFollow: anchor.NoFollow})
+	}
+
+	return links
+}
+
+// self - returns the canonicalized url of the fetched document after redirects
+func (c *crawler) self(t task, resp *fetcher.Response) string {
+	if resp.FinalURL == "" {
+		return t.url
+	}
+
+	u, err := c.canonicalize(resp.FinalURL)
+	if err != nil {
+		return t.url
+	}
+
+	return u
+}
+
+// pageCanonical - returns the canonicalized canonical url of the page, empty if it has none or it is not valid
+func (c *crawler) pageCanonical(ctx context.Context, resp *fetcher.Response) string {
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+
+	if resp.Page == nil || resp.Page.Canonical() == "" {
+		return ""
+	}
+
+	u, err := c.canonicalize(resp.Page.Canonical())
+	if err != nil {
+		log.Debug().Err(err).Msgf("canonical url %s is skipped", resp.Page.Canonical())
+
+		return ""
+	}
+
+	return u
+}
+
+// indexability - returns the indexability of a successful response, noindex takes precedence over the canonical url
+func indexability(resp *fetcher.Response, canonical, self string) string {
+	switch {
+	case resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices:
+		return ""
+	case resp.Robots.NoIndex:
+		return IndexabilityNoIndex
+	case canonical != "" && canonical != self:
+		return IndexabilityCanonicalized
+	default:
+		return IndexabilityIndexable
+	}
+}
+
+// followable - returns the links of the page allowed by its directives unless they are ignored: none for nofollow pages
+// and only the canonical link for pages canonicalized to another page in the scope
+func (c *crawler) followable(ctx context.Context, t task, resp *fetcher.Response, r Result, self string) []parser.Link {
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
 
-			go c.Crawl(ctx, cancel, link, false, depth+1, errCh)
+	if resp.Robots.NoFollow && !c.directives.IgnoreNoFollow {
+		log.Debug().Msgf("url %s has a nofollow directive - links are not followed", t.url)
+
+		return nil
+	}
+
+	if c.directives.IgnoreCanonical || r.Canonical == "" || r.Canonical == self ||
+		(t.scope != nil && !t.scope.Contains(r.Canonical)) {
+		return r.Links
+	}
+
+	log.Debug().Msgf("url %s is canonicalized to %s - only the canonical link is followed", t.url, r.Canonical)
+
+	for _, link := range r.Links {
+		if link.URL == r.Canonical && link.Kind == config.LinkCanonical {
+			return []parser.Link{link}
+		}
+	}
+
+	return nil
+}
+
+// links - returns links of the page of the followed kinds in the scope of the task,
+// out of scope links and links of the reported kinds are checked or reported, other links are ignored,
+// the links of a checked leaf are only kept, so they are neither checked nor reported
+func (c *crawler) links(ctx context.Context, t task, anchors []parser.Link) []parser.Link {
+	links := make([]parser.Link, 0, len(anchors))
+
+	for _, anchor := range anchors {
+		if anchor.NoFollow && !c.directives.IgnoreNoFollow {
+			continue
+		}
+
+		kind := linkKind(anchor)
+
+		switch {
+		case contains(c.follow, kind):
+		case contains(c.report, kind):
+			if !t.leaf {
+				c.notFollowed(ctx, t.child(anchor))
+			}
+
+			continue
+		default:
+			continue
 		}
 
-		if withPanic {
-			panic(url)
+		if t.scope != nil && !t.scope.Contains(anchor.URL) {
+			if !t.leaf {
+				c.outOfScope(ctx, t.child(anchor))
+			}
+
+			continue
 		}
+
+		links = append(links, anchor)
+	}
+
+	return links
+}
+
+// notFollowed - puts a link of a reported kind into the frontier to be checked once in the link check mode,
+// outputs it once otherwise
+func (c *crawler) notFollowed(ctx context.Context, t task) {
+	if !c.tryVisit(t.url) {
+		return
+	}
+
+	if c.linkCheck == nil || !isHTTP(t.url) {
+		c.checkpointVisit(ctx, t.url)
+		c.send(ctx, t.skipped(SkipNotFollowed))
+
+		return
+	}
+
+	t.external = true
+
+	c.IncCnt()
+	c.push(ctx, t)
+}
+
+// outOfScope - puts an out of scope link into the frontier when external links are checked, reports it otherwise
+func (c *crawler) outOfScope(ctx context.Context, t task) {
+	if c.linkCheck == nil || !c.linkCheck.External || !isHTTP(t.url) {
+		c.skipOutOfScope(ctx, t)
+
+		return
+	}
+
+	if !c.tryVisit(t.url) {
+		return
+	}
+
+	t.external = true
+
+	c.IncCnt()
+	c.push(ctx, t)
+}
+
+// fetchFailed - outputs the failed url in the link check mode, passes the error to errCh otherwise
+func (c *crawler) fetchFailed(ctx context.Context, t task, retries int, err error, errCh chan<- error) {
+	if c.linkCheck == nil || ctx.Err() != nil {
+		c.sendErr(ctx, errCh, err)
+
+		return
 	}
+
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+	log.Debug().Err(err).Msgf("url %s is broken", t.url)
+
+	r := t.skipped("")
+	r.Error = fetcher.Describe(err)
+	r.Retries = retries
+
+	c.send(ctx, r)
+}
+
+func (c *crawler) canonicalize(uri string) (string, error) {
+	if c.canonicalizer == nil {
+		return uri, nil
+	}
+
+	canonicalURL, err := c.canonicalizer.Canonicalize(uri)
+	if err != nil {
+		return "", errors.Wrap(err, "crawler canonicalization")
+	}
+
+	return canonicalURL, nil
+}
+
+// seedScope - returns the scope of the seed or the global one, nil if neither is configured
+func (c *crawler) seedScope(seed string) (scope.Scope, error) {
+	cfg := c.scope
+	if s, ok := c.seedSetting(seed); ok && s.Scope != nil {
+		cfg = s.Scope
+	}
+
+	if cfg == nil {
+		return nil, nil
+	}
+
+	s, err := scope.New(*cfg, seed)
+	if err != nil {
+		return nil, errors.Wrap(err, "crawler scope")
+	}
+
+	return s, nil
+}
+
+// skipOutOfScope - outputs an out of scope link once if it is configured
+func (c *crawler) skipOutOfScope(ctx context.Context, t task) {
+	if c.scope == nil || !c.scope.ReportOutOfScope || !c.tryVisit(t.url) {
+		return
+	}
+
+	c.checkpointVisit(ctx, t.url)
+
+	c.send(ctx, t.skipped(SkipOutOfScope))
+}
+
+// skipReason - returns why the url of the task must not be fetched or an empty string,
+// external links are checked with a single request and are not subject to robots.txt
+func (c *crawler) skipReason(ctx context.Context, t task) (string, error) {
+	if c.robots != nil && !t.external {
+		allowed, err := c.robots.Allowed(ctx, t.url)
+		if err != nil {
+			return "", errors.Wrap(err, "crawler robots")
+		}
+
+		if !allowed {
+			return SkipDisallowedByRobots, nil
+		}
+	}
+
+	return "", nil
+}
+
+// send - outputs the result once and reports whether it has been delivered before ctx is done
+func (c *crawler) send(ctx context.Context, r Result) bool {
+	if c.emitted(ctx, r.URL) {
+		return true
+	}
+
+	if c.checkpoint != nil {
+		r.written = make(chan struct{})
+	}
+
+	select {
+	case c.result <- r:
+	case <-ctx.Done():
+		return false
+	}
+
+	if r.written == nil {
+		return true
+	}
+
+	// the result is recorded as emitted only when it can not be lost by the output, the task is done after it
+	select {
+	case <-r.written:
+		c.checkpointEmit(ctx, r)
+
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *crawler) sendErr(ctx context.Context, errCh chan<- error, err error) {
+	select {
+	case errCh <- err:
+	case <-ctx.Done():
+	}
+}
+
+// result - returns the crawl result of the fetched task
+func (t task) result(resp *fetcher.Response) Result {
+	r := t.skipped("")
+	r.StatusCode = resp.StatusCode
+	r.FinalURL = resp.FinalURL
+	r.Redirects = resp.Redirects
+	r.ContentType = resp.ContentType
+	r.ContentLength = resp.ContentLength
+	r.Truncated = resp.Truncated
+	r.Charset = resp.Charset
+	r.ResponseTime = resp.ResponseTime
+	r.LastModified = resp.LastModified
+
+	if resp.Certificate != nil {
+		r.CertIssuer = resp.Certificate.Issuer
+		r.CertExpires = resp.Certificate.Expires
+	}
+
+	if resp.Page != nil {
+		r.Title = resp.Page.Title()
+	}
+
+	return r
+}
+
+// skipped - returns the crawl result of the task which has not been fetched
+func (t task) skipped(reason string) Result {
+	return Result{ //nolint:exhaustivestruct
+		URL:      t.url,
+		Skipped:  reason,
+		Depth:    t.depth,
+		Referrer: t.referrer,
+		Kind:     t.kind,
+		Source:   t.source,
+		External: t.external,
+	}
+}
+
+// fetch - waits for the host breaker and politeness rules and fetches the url of the task once,
+// external links are checked with HEAD requests if configured
+func (c *crawler) fetch(ctx context.Context, t task) (resp *fetcher.Response, err error) {
+	var u *url.URL
+
+	if u, err = url.Parse(t.url); err != nil {
+		return nil, errors.Wrap(err, "crawler url parsing")
+	}
+
+	if c.breaker != nil {
+		if err = c.breaker.Wait(ctx, u.Host); err != nil {
+			return nil, errors.Wrap(err, "crawler breaker")
+		}
+	}
+
+	if c.politeness != nil {
+		var release func()
+
+		if release, err = c.politeness.Acquire(ctx, u.Host); err != nil {
+			return nil, errors.Wrap(err, "crawler politeness")
+		}
+
+		defer release()
+	}
+
+	if t.external && c.linkCheck != nil && c.linkCheck.HeadExternal {
+		resp, err = c.fetcher.Head(ctx, t.url)
+	} else {
+		resp, err = c.fetcher.Fetch(ctx, t.url)
+	}
+
+	c.recordHealth(ctx, u.Host, resp, err)
+
+	return resp, err
+}
+
+func isHTTP(uri string) bool {
+	u, err := url.Parse(uri)
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
 }
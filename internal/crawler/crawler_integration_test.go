@@ -14,6 +14,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/parser"
 )
 
 func TestCrawl(t *testing.T) {
@@ -58,24 +59,38 @@ func TestCrawl(t *testing.T) {
 LOOP:
 	for {
 		select {
-		case <-ctx.Done():
-			cancel()
 		case err := <-errCh:
 			assert.Nil(t, err)
-		case res := <-c.ResultCh():
-			assert.Equal(t, Result{
-				URL:   "http://localhost:8080/",
-				Title: "Home page",
-			}, res)
-		default:
-			if c.GetCnt() != 0 {
-				break
+		case res, ok := <-c.ResultCh():
+			if !ok {
+				break LOOP
 			}
 
-			break LOOP
+			assert.Greater(t, res.ResponseTime, time.Duration(0))
+
+			// the response time differs from run to run
+			res.ResponseTime = 0
+
+			assert.Equal(t, Result{ //nolint:exhaustivestruct
+				URL:           "http://localhost:8080/",
+				Title:         "Home page",
+				Skipped:       "",
+				StatusCode:    http.StatusOK,
+				FinalURL:      "http://localhost:8080/",
+				ContentType:   "text/html; charset=utf-8",
+				ContentLength: 127,
+				Charset:       "utf-8",
+				Indexability:  IndexabilityIndexable,
+				Depth:         0,
+				Referrer:      "",
+				Links:         []parser.Link{},
+			}, res)
 		}
 	}
 
+	<-c.Done()
+	assert.Equal(t, int64(0), c.GetCnt())
+
 	err := server.Shutdown(ctx)
 	assert.Nil(t, err)
 }
@@ -1,9 +1,21 @@
 package crawler
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/fetcher"
+	"github.com/vfunin/crawler/internal/retry"
+	"github.com/vfunin/crawler/internal/sitemap"
 )
 
 func TestNew(t *testing.T) {
@@ -46,3 +58,477 @@ func TestResultCh(t *testing.T) {
 	c := New(0, 0)
 	assert.NotEqual(t, c.ResultCh(), make(<-chan Result))
 }
+
+func TestWithWorkers(t *testing.T) {
+	c := New(0, 0, WithWorkers(3)).(*crawler)
+	assert.Equal(t, 3, c.workers)
+
+	c = New(0, 0, WithWorkers(0)).(*crawler)
+	assert.Equal(t, config.DefaultWorkers, c.workers)
+}
+
+func TestDone(t *testing.T) {
+	ctx := context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop())
+	c := New(0, 0)
+
+	go c.Crawl(ctx, func() {}, "", false, 0, make(chan error))
+
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("crawl without urls is not finished")
+	}
+
+	_, ok := <-c.ResultCh()
+	assert.False(t, ok)
+}
+
+func TestCrawlResultDetails(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<title>home</title><a href="/old">old</a><a href="/missing">missing</a>`)
+	})
+	mux.Handle("/old", http.RedirectHandler("/new", http.StatusMovedPermanently))
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<title>new</title>`)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, `<title>not found</title><a href="/never">never</a>`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	c := New(2, 1)
+	errCh := make(chan error, 10)
+	seed := server.URL + "/"
+
+	go c.Crawl(ctx, cancel, seed, false, 0, errCh)
+
+	results := make(map[string]Result)
+
+	for res := range c.ResultCh() {
+		results[res.URL] = res
+	}
+
+	assert.Len(t, results, 3)
+
+	home := results[seed]
+	assert.Equal(t, http.StatusOK, home.StatusCode)
+	assert.Equal(t, "home", home.Title)
+	assert.Equal(t, uint64(0), home.Depth)
+	assert.Empty(t, home.Referrer)
+	assert.Contains(t, home.ContentType, "text/html")
+	assert.Positive(t, home.ContentLength)
+
+	old := results[server.URL+"/old"]
+	assert.Equal(t, http.StatusOK, old.StatusCode)
+	assert.Equal(t, "new", old.Title)
+	assert.Equal(t, server.URL+"/new", old.FinalURL)
+	assert.Equal(t, []string{server.URL + "/old"}, old.Redirects)
+	assert.Equal(t, uint64(1), old.Depth)
+	assert.Equal(t, seed, old.Referrer)
+
+	missing := results[server.URL+"/missing"]
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+	assert.Empty(t, missing.Title)
+	assert.Equal(t, seed, missing.Referrer)
+}
+
+func TestCrawlLinkCheck(t *testing.T) {
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer external.Close()
+
+	// the same host with another name is out of the scope of the seed
+	externalURL := strings.Replace(external.URL, "127.0.0.1", "localhost", 1)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	defer server.Close()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `<a href="/missing">Missing</a><a href="%s/gone">Gone</a><a href="http://127.0.0.1:1/">Refused</a>`,
+			externalURL)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	c := New(2, 1,
+		WithScope(config.Scope{SameHost: true}), //nolint:exhaustivestruct
+		WithLinkCheck(config.LinkCheck{Enabled: true, External: true, HeadExternal: true}),
+	)
+	errCh := make(chan error, 10)
+	seed := server.URL + "/"
+
+	go c.Crawl(ctx, cancel, seed, false, 0, errCh)
+
+	results := make(map[string]Result)
+
+	for res := range c.ResultCh() {
+		results[res.URL] = res
+	}
+
+	assert.Empty(t, errCh)
+	assert.Len(t, results, 4)
+	assert.Len(t, results[seed].Links, 3)
+	assert.Equal(t, "Missing", results[seed].Links[0].Text)
+	assert.Equal(t, http.StatusNotFound, results[server.URL+"/missing"].StatusCode)
+	assert.Equal(t, http.StatusGone, results[externalURL+"/gone"].StatusCode)
+	assert.Equal(t, seed, results[externalURL+"/gone"].Referrer)
+	assert.True(t, results[externalURL+"/gone"].External)
+	assert.False(t, results[server.URL+"/missing"].External)
+	assert.Equal(t, fetcher.FailureConnection, results["http://127.0.0.1:1/"].Error)
+}
+
+func TestCrawlLinkCheckLeaves(t *testing.T) {
+	var deeper int32
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	defer server.Close()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<a href="/page">Page</a><a href="/missing">Missing</a>`)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<a href="/deeper">Deeper</a><a href="http://other.test/">Other</a>`)
+	})
+	mux.HandleFunc("/deeper", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&deeper, 1)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	c := New(0, 1,
+		WithScope(config.Scope{SameHost: true}), //nolint:exhaustivestruct
+		WithLinkCheck(config.LinkCheck{Enabled: true, External: true, HeadExternal: false}),
+	)
+	seed := server.URL + "/"
+
+	go c.Crawl(ctx, cancel, seed, false, 0, make(chan error, 10))
+
+	results := make(map[string]Result)
+
+	for res := range c.ResultCh() {
+		results[res.URL] = res
+	}
+
+	assert.Len(t, results, 3, "the links of the pages at the depth limit are checked once")
+	assert.Equal(t, http.StatusOK, results[server.URL+"/page"].StatusCode)
+	assert.Equal(t, http.StatusNotFound, results[server.URL+"/missing"].StatusCode)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&deeper), "the links of the checked pages are not followed")
+	assert.Contains(t, c.(*crawler).leaves, server.URL+"/deeper", "they are kept for a deeper crawl")
+}
+
+func TestCrawlLinkKinds(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	defer server.Close()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<link rel="alternate" href="/feed"><a href="/page">Page</a><img src="/logo.png" alt="Logo">`)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<title>Page</title>`)
+	})
+	mux.HandleFunc("/logo.png", http.NotFound)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	links := config.DefaultLinks()
+	links.Report = []string{config.LinkAsset}
+
+	c := New(2, 1, WithLinks(links))
+	errCh := make(chan error, 10)
+	seed := server.URL + "/"
+
+	go c.Crawl(ctx, cancel, seed, false, 0, errCh)
+
+	results := make(map[string]Result)
+
+	for res := range c.ResultCh() {
+		results[res.URL] = res
+	}
+
+	assert.Empty(t, errCh)
+	assert.Len(t, results, 3)
+	assert.Equal(t, config.LinkNavigation, results[server.URL+"/page"].Kind)
+	assert.Equal(t, http.StatusOK, results[server.URL+"/page"].StatusCode)
+	assert.Equal(t, config.LinkAsset, results[server.URL+"/logo.png"].Kind)
+	assert.Equal(t, SkipNotFollowed, results[server.URL+"/logo.png"].Skipped)
+	assert.Zero(t, results[server.URL+"/logo.png"].StatusCode)
+	assert.NotContains(t, results, server.URL+"/feed")
+}
+
+func TestCrawlDirectives(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	defer server.Close()
+
+	page := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = fmt.Fprint(w, body)
+		}
+	}
+
+	mux.HandleFunc("/", page(`<a href="/sponsored" rel="nofollow">Ad</a><a href="/hidden">Hidden</a><a href="/copy">Copy</a>`))
+	mux.HandleFunc("/hidden", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+		page(`<a href="/behind">Behind</a>`)(w, r)
+	})
+	mux.HandleFunc("/copy", page(`<link rel="canonical" href="/original"><a href="/duplicate">Duplicate</a>`))
+	mux.HandleFunc("/original", page(`<link rel="canonical" href="/original"><title>Original</title>`))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	crawl := func(opts ...Option) map[string]Result {
+		c := New(3, 1, opts...)
+		errCh := make(chan error, 10)
+
+		go c.Crawl(ctx, cancel, server.URL+"/", false, 0, errCh)
+
+		results := make(map[string]Result)
+
+		for res := range c.ResultCh() {
+			results[strings.TrimPrefix(res.URL, server.URL)] = res
+		}
+
+		assert.Empty(t, errCh)
+
+		return results
+	}
+
+	results := crawl()
+	assert.Len(t, results, 4)
+	assert.NotContains(t, results, "/sponsored")
+	assert.NotContains(t, results, "/behind")
+	assert.NotContains(t, results, "/duplicate")
+	assert.Equal(t, IndexabilityIndexable, results["/"].Indexability)
+	assert.Equal(t, IndexabilityNoIndex, results["/hidden"].Indexability)
+	assert.Equal(t, IndexabilityCanonicalized, results["/copy"].Indexability)
+	assert.Equal(t, server.URL+"/original", results["/copy"].Canonical)
+	assert.Equal(t, IndexabilityIndexable, results["/original"].Indexability)
+	assert.Equal(t, config.LinkCanonical, results["/original"].Kind)
+
+	results = crawl(WithDirectives(config.Directives{IgnoreNoFollow: true, IgnoreCanonical: true}))
+	assert.Len(t, results, 7)
+	assert.Contains(t, results, "/sponsored")
+	assert.Contains(t, results, "/behind")
+	assert.Contains(t, results, "/duplicate")
+	assert.Equal(t, IndexabilityCanonicalized, results["/copy"].Indexability)
+}
+
+// sitemaps - lists the same entries for every seed
+type sitemaps []sitemap.Entry
+
+func (s sitemaps) Entries(_ context.Context, _ string) ([]sitemap.Entry, error) {
+	return s, nil
+}
+
+func TestCrawlSitemaps(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	defer server.Close()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<a href="/linked">Linked</a>`)
+	})
+	mux.HandleFunc("/orphan", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<a href="/behind">Behind</a>`)
+	})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	source := server.URL + "/sitemap.xml"
+	c := New(1, 1,
+		WithScope(config.Scope{SameHost: true, ReportOutOfScope: true}), //nolint:exhaustivestruct
+		WithSitemaps(sitemaps{
+			{URL: server.URL + "/orphan", Source: source},
+			{URL: server.URL + "/", Source: source},
+			{URL: "http://other.test/", Source: source},
+		}),
+	)
+	errCh := make(chan error, 10)
+
+	go c.Crawl(ctx, cancel, server.URL+"/", false, 0, errCh)
+
+	results := make(map[string]Result)
+
+	for res := range c.ResultCh() {
+		results[strings.TrimPrefix(res.URL, server.URL)] = res
+	}
+
+	assert.Empty(t, errCh)
+	assert.Len(t, results, 5)
+	assert.Empty(t, results["/"].Source, "the seed keeps its own source")
+	assert.Equal(t, source, results["/orphan"].Source)
+	assert.Equal(t, uint64(0), results["/orphan"].Depth)
+	assert.Empty(t, results["/orphan"].Referrer)
+	assert.Equal(t, uint64(1), results["/behind"].Depth)
+	assert.Empty(t, results["/behind"].Source)
+	assert.Equal(t, SkipOutOfScope, results["http://other.test/"].Skipped)
+}
+
+// cancelledSitemaps - lists the entries a while after the crawl is cancelled
+type cancelledSitemaps struct {
+	sitemaps
+	returned *int32
+}
+
+func (s cancelledSitemaps) Entries(ctx context.Context, seed string) ([]sitemap.Entry, error) {
+	<-ctx.Done()
+	time.Sleep(50 * time.Millisecond)
+	atomic.StoreInt32(s.returned, 1)
+
+	return s.sitemaps.Entries(ctx, seed)
+}
+
+func TestCrawlSitemapsCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<title>page</title>`)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	var returned int32
+
+	c := New(1, 1,
+		WithScope(config.Scope{SameHost: true, ReportOutOfScope: true}), //nolint:exhaustivestruct
+		WithSitemaps(cancelledSitemaps{sitemaps: sitemaps{{URL: "http://other.test/", Source: ""}}, returned: &returned}),
+	)
+
+	go c.Crawl(ctx, cancel, server.URL+"/", false, 0, make(chan error, 10))
+
+	<-c.ResultCh()
+	cancel()
+
+	for range c.ResultCh() {
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&returned), "the results are closed after the sitemaps goroutine returns")
+}
+
+func TestCrawlSeeds(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+
+		switch r.URL.Path {
+		case "/":
+			_, _ = fmt.Fprint(w, `<a href="/docs/">Docs</a><a href="/blog/">Blog</a>`)
+		case "/docs/":
+			_, _ = fmt.Fprint(w, `<a href="/docs/deep">Deep</a>`)
+		default:
+			_, _ = fmt.Fprint(w, `<title>page</title>`)
+		}
+	}
+
+	first := httptest.NewServer(http.HandlerFunc(handler))
+	defer first.Close()
+
+	second := httptest.NewServer(http.HandlerFunc(handler))
+	defer second.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	shallow := uint64(0)
+	docs := config.Scope{SameHost: true, Include: []string{`/docs/`}} //nolint:exhaustivestruct
+	c := New(2, 1,
+		WithScope(config.Scope{SameHost: true}), //nolint:exhaustivestruct
+		WithSeeds([]config.Seed{
+			{URL: first.URL + "/", MaxDepth: &shallow, Scope: nil},
+			{URL: second.URL + "/docs/", MaxDepth: nil, Scope: &docs},
+		}),
+	)
+	errCh := make(chan error, 10)
+
+	c.IncCnt()
+
+	go c.Crawl(ctx, cancel, first.URL+"/", false, 0, errCh)
+	go c.Crawl(ctx, cancel, second.URL+"/docs/", false, 0, errCh)
+
+	var urls []string
+
+	for res := range c.ResultCh() {
+		urls = append(urls, res.URL)
+	}
+
+	assert.Empty(t, errCh)
+	assert.ElementsMatch(t, []string{first.URL + "/", second.URL + "/docs/", second.URL + "/docs/deep"}, urls)
+}
+
+func TestCrawlRetries(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `<title>recovered</title>`)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop()))
+	defer cancel()
+
+	cfg := config.Retry{ //nolint:exhaustivestruct
+		MaxRetries:       3,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       10 * time.Millisecond,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Second,
+	}
+	c := New(0, 1, WithRetry(retry.New(cfg)), WithBreaker(retry.NewBreaker(cfg)))
+	errCh := make(chan error, 10)
+
+	go c.Crawl(ctx, cancel, server.URL+"/", false, 0, errCh)
+
+	res := <-c.ResultCh()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "recovered", res.Title)
+	assert.Equal(t, 2, res.Retries)
+
+	<-c.Done()
+}
+
+func TestRecoverAndCountRuntimeError(t *testing.T) {
+	c := New(0, 0).(*crawler)
+
+	assert.NotPanics(t, func() {
+		defer c.recoverAndCount(zerolog.Nop())
+
+		var pages map[string]int
+		pages["http://go.test/"]++
+	})
+	assert.Equal(t, int64(0), c.GetCnt())
+}
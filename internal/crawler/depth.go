@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"context"
+
+	"github.com/vfunin/crawler/internal/parser"
+)
+
+// maxLeaves - links remembered at the depth limit, the links found when there are that many not visited ones
+// are not crawled when the limit is raised
+const maxLeaves = 100000
+
+// keepLeaf - remembers the not visited links of a page at the depth limit so they can be crawled when the limit
+// is raised, reports whether the page is a leaf
+func (c *crawler) keepLeaf(t task, links []parser.Link) bool {
+	c.leavesMu.Lock()
+	defer c.leavesMu.Unlock()
+
+	if c.canGoDeeper(t.seed, t.depth+1) {
+		return false
+	}
+
+	for _, link := range links {
+		if c.leavesFull {
+			break
+		}
+
+		if _, ok := c.leaves[link.URL]; ok || c.isVisited(link.URL) {
+			continue
+		}
+
+		if len(c.leaves) >= maxLeaves && !c.pruneLeaves() {
+			break
+		}
+
+		c.leaves[link.URL] = t.child(link)
+	}
+
+	return true
+}
+
+// checkLeaves - puts the links of a page at the depth limit into the frontier to be checked once in the link check
+// mode, reports whether the page is at the limit, the links of the checked pages are kept for a deeper crawl
+func (c *crawler) checkLeaves(ctx context.Context, t task, links []parser.Link) bool {
+	if c.linkCheck == nil || t.leaf || c.canGoDeeper(t.seed, t.depth+1) {
+		return false
+	}
+
+	for _, link := range links {
+		if !c.tryVisit(link.URL) {
+			continue
+		}
+
+		leaf := t.child(link)
+		leaf.leaf = true
+
+		c.IncCnt()
+		c.push(ctx, leaf)
+	}
+
+	return true
+}
+
+// pruneLeaves - forgets the links visited since they were remembered and reports whether there is room for
+// another one, no more links are remembered until the limit is raised otherwise, must be called with leavesMu held
+func (c *crawler) pruneLeaves() bool {
+	for link := range c.leaves {
+		if c.isVisited(link) {
+			delete(c.leaves, link)
+		}
+	}
+
+	c.leavesFull = len(c.leaves) >= maxLeaves
+
+	return !c.leavesFull
+}
+
+// takeLeaves - removes and returns the remembered links within maxDepth, must be called with leavesMu held
+func (c *crawler) takeLeaves(maxDepth uint64) (tasks []task) {
+	for link, t := range c.leaves {
+		if t.depth > c.seedMaxDepth(t.seed, maxDepth) {
+			continue
+		}
+
+		tasks = append(tasks, t)
+		delete(c.leaves, link)
+	}
+
+	// the visited links are pruned again when the next link is remembered
+	c.leavesFull = false
+
+	return tasks
+}
+
+// deepen - puts the not visited leaf links into the frontier of the running crawl,
+// nothing is added when the crawl is already finished
+func (c *crawler) deepen(tasks []task) {
+	c.leavesMu.Lock()
+	ctx := c.crawlCtx
+	c.leavesMu.Unlock()
+
+	if ctx == nil {
+		return
+	}
+
+	for _, t := range tasks {
+		if !c.tryVisit(t.url) {
+			continue
+		}
+
+		c.IncCnt()
+
+		if !c.push(ctx, t) {
+			c.decCnt()
+
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/parser"
+)
+
+// navigation - returns navigation links of the urls
+func navigation(urls ...string) []parser.Link {
+	links := make([]parser.Link, 0, len(urls))
+	for _, u := range urls {
+		links = append(links, parser.Link{URL: u, Text: "", Kind: config.LinkNavigation})
+	}
+
+	return links
+}
+
+func TestIncMaxDepthDeepensLeaves(t *testing.T) {
+	ctx := context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop())
+	c := New(0, 0).(*crawler)
+	c.crawlCtx = ctx
+
+	leaf := task{url: "http://go.test/", depth: 0, withPanic: false, seed: "http://go.test/", scope: nil}
+
+	assert.True(t, c.keepLeaf(leaf, navigation("http://go.test/a", "http://go.test/b")))
+	assert.True(t, c.keepLeaf(leaf, navigation("http://go.test/a")))
+	assert.Equal(t, 2, len(c.leaves))
+
+	c.tryVisit("http://go.test/b")
+	c.IncMaxDepth(1)
+
+	assert.Equal(t, uint64(1), c.MaxDepth())
+	assert.Empty(t, c.leaves)
+	assert.Equal(t, int64(2), c.GetCnt())
+
+	got, ok := c.frontier.pop(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "http://go.test/a", got.url)
+	assert.Equal(t, uint64(1), got.depth)
+	assert.True(t, c.keepLeaf(got, navigation("http://go.test/c")))
+}
+
+func TestIncMaxDepthKeepsDeeperLeaves(t *testing.T) {
+	ctx := context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop())
+	c := New(0, 0).(*crawler)
+	c.crawlCtx = ctx
+
+	c.keepLeaf(task{url: "http://go.test/", depth: 0, withPanic: false, seed: "", scope: nil}, navigation("http://go.test/a"))
+	c.keepLeaf(task{url: "http://go.test/x", depth: 1, withPanic: false, seed: "", scope: nil}, navigation("http://go.test/b"))
+
+	c.IncMaxDepth(1)
+
+	assert.Equal(t, 1, len(c.leaves))
+	assert.Contains(t, c.leaves, "http://go.test/b")
+}
+
+func TestIncMaxDepthAfterFinish(t *testing.T) {
+	ctx := context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop())
+	c := New(0, 0).(*crawler)
+	c.crawlCtx = ctx
+
+	c.keepLeaf(task{url: "http://go.test/", depth: 0, withPanic: false, seed: "", scope: nil}, navigation("http://go.test/a"))
+	c.DecCnt()
+	c.IncMaxDepth(1)
+
+	assert.Equal(t, int64(0), c.GetCnt())
+}
+
+func TestIncMaxDepthRaisesSeedDepth(t *testing.T) {
+	ctx := context.WithValue(context.Background(), config.LoggerCtxKey, zerolog.Nop())
+	shallow := uint64(0)
+	c := New(2, 0, WithSeeds([]config.Seed{{URL: "http://shallow.test/", MaxDepth: &shallow, Scope: nil}})).(*crawler)
+	c.crawlCtx = ctx
+
+	shallowSeed := task{url: "http://shallow.test/", depth: 0, withPanic: false, seed: "http://shallow.test/", scope: nil}
+	assert.True(t, c.keepLeaf(shallowSeed, navigation("http://shallow.test/a")))
+	assert.False(t, c.keepLeaf(task{url: "http://go.test/", depth: 0, withPanic: false, seed: "http://go.test/", scope: nil},
+		navigation("http://go.test/a")))
+
+	c.IncMaxDepth(1)
+
+	assert.Empty(t, c.leaves)
+	assert.Equal(t, uint64(1), c.seedMaxDepth("http://shallow.test/", c.MaxDepth()))
+	assert.Equal(t, uint64(3), c.seedMaxDepth("http://go.test/", c.MaxDepth()))
+}
+
+func TestKeepLeafSkipsVisited(t *testing.T) {
+	c := New(0, 0).(*crawler)
+
+	c.tryVisit("http://go.test/a")
+
+	assert.True(t, c.keepLeaf(task{url: "http://go.test/", depth: 0, withPanic: false, seed: "", scope: nil},
+		navigation("http://go.test/a", "http://go.test/b")))
+	assert.Equal(t, 1, len(c.leaves))
+	assert.Contains(t, c.leaves, "http://go.test/b")
+}
+
+func TestKeepLeafLimit(t *testing.T) {
+	c := New(0, 0).(*crawler)
+	leaf := task{url: "http://go.test/", depth: 0, withPanic: false, seed: "", scope: nil}
+
+	for i := 0; i < maxLeaves; i++ {
+		c.leaves["http://go.test/"+strconv.Itoa(i)] = leaf.child(parser.Link{URL: "http://go.test/" + strconv.Itoa(i), Text: "", Kind: ""})
+	}
+
+	c.tryVisit("http://go.test/0")
+
+	assert.True(t, c.keepLeaf(leaf, navigation("http://go.test/a", "http://go.test/b")))
+	assert.Equal(t, maxLeaves, len(c.leaves), "the visited link makes room for one more")
+	assert.NotContains(t, c.leaves, "http://go.test/0")
+	assert.Contains(t, c.leaves, "http://go.test/a")
+	assert.True(t, c.leavesFull)
+
+	c.tryVisit("http://go.test/1")
+	c.keepLeaf(leaf, navigation("http://go.test/c"))
+	assert.NotContains(t, c.leaves, "http://go.test/c", "no links are remembered until the limit is raised")
+
+	c.IncMaxDepth(1)
+
+	assert.Empty(t, c.leaves)
+	assert.False(t, c.leavesFull)
+}
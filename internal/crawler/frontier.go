@@ -0,0 +1,145 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/parser"
+	"github.com/vfunin/crawler/internal/scope"
+)
+
+type task struct {
+	url       string
+	depth     uint64
+	withPanic bool
+	// seed - url the task has been discovered from
+	seed string
+	// scope - scope of the seed the task has been discovered from, nil means unrestricted
+	scope scope.Scope
+	// referrer - url of the page linking to the task, empty for seeds
+	referrer string
+	// external - link out of the scope which is checked but not crawled
+	external bool
+	// leaf - link of a page at the depth limit which is checked in the link check mode, its links are kept
+	// for a deeper crawl instead of being followed or checked
+	leaf bool
+	// kind - kind of the link the task has been found as, empty for seeds
+	kind string
+	// source - url of the sitemap or the feed the task has been listed in, empty for seeds and links
+	source string
+}
+
+// child - returns the task of a link found on the page of t
+func (t task) child(link parser.Link) task {
+	return task{
+		url:       link.URL,
+		depth:     t.depth + 1,
+		withPanic: false,
+		seed:      t.seed,
+		scope:     t.scope,
+		referrer:  t.url,
+		external:  false,
+		leaf:      false,
+		kind:      linkKind(link),
+		source:    "",
+	}
+}
+
+// linkKind - returns the kind of the link, links without one are navigation links
+func linkKind(link parser.Link) string {
+	if link.Kind == "" {
+		return config.LinkNavigation
+	}
+
+	return link.Kind
+}
+
+// frontier - unbounded FIFO queue of pending tasks shared by the crawl workers
+type frontier struct {
+	mu     sync.Mutex
+	tasks  []task
+	notify chan struct{}
+	done   chan struct{}
+	closed bool
+}
+
+func newFrontier() *frontier {
+	return &frontier{
+		mu:     sync.Mutex{},
+		tasks:  nil,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		closed: false,
+	}
+}
+
+// push - adds the task to the queue and reports whether the frontier is still open
+func (f *frontier) push(t task) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return false
+	}
+
+	f.tasks = append(f.tasks, t)
+	f.signal()
+
+	return true
+}
+
+// pop - blocks until a task is available, the frontier is closed or the context is done
+func (f *frontier) pop(ctx context.Context) (t task, ok bool) {
+	for {
+		f.mu.Lock()
+
+		if len(f.tasks) > 0 {
+			t = f.tasks[0]
+			f.tasks[0] = task{} //nolint:exhaustivestruct
+			f.tasks = f.tasks[1:]
+
+			if len(f.tasks) > 0 {
+				f.signal()
+			}
+
+			f.mu.Unlock()
+
+			return t, true
+		}
+
+		closed := f.closed
+		f.mu.Unlock()
+
+		if closed {
+			return t, false
+		}
+
+		select {
+		case <-ctx.Done():
+			return t, false
+		case <-f.done:
+		case <-f.notify:
+		}
+	}
+}
+
+func (f *frontier) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+
+	f.closed = true
+	close(f.done)
+}
+
+// signal - wakes up one waiting worker, must be called with the lock held
+func (f *frontier) signal() {
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrontierFIFO(t *testing.T) {
+	f := newFrontier()
+	f.push(task{url: "a", depth: 0, withPanic: false})
+	f.push(task{url: "b", depth: 1, withPanic: false})
+
+	got, ok := f.pop(context.Background())
+	assert.True(t, ok)
+	assert.Equal(t, "a", got.url)
+
+	got, ok = f.pop(context.Background())
+	assert.True(t, ok)
+	assert.Equal(t, "b", got.url)
+	assert.Equal(t, uint64(1), got.depth)
+}
+
+func TestFrontierClose(t *testing.T) {
+	f := newFrontier()
+	popped := make(chan bool)
+
+	go func() {
+		_, ok := f.pop(context.Background())
+		popped <- ok
+	}()
+
+	f.close()
+
+	select {
+	case ok := <-popped:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("pop is not released by close")
+	}
+
+	f.push(task{url: "a", depth: 0, withPanic: false})
+
+	_, ok := f.pop(context.Background())
+	assert.False(t, ok)
+}
+
+func TestFrontierContextDone(t *testing.T) {
+	f := newFrontier()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	_, ok := f.pop(ctx)
+	assert.False(t, ok)
+}
+
+func TestFrontierWakesAllWorkers(t *testing.T) {
+	f := newFrontier()
+	popped := make(chan string, 3)
+
+	for i := 0; i < 3; i++ {
+		go func() {
+			if got, ok := f.pop(context.Background()); ok {
+				popped <- got.url
+			}
+		}()
+	}
+
+	f.push(task{url: "a", depth: 0, withPanic: false})
+	f.push(task{url: "b", depth: 0, withPanic: false})
+	f.push(task{url: "c", depth: 0, withPanic: false})
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-popped:
+		case <-time.After(time.Second):
+			t.Fatal("not every task is popped")
+		}
+	}
+}
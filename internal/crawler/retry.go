@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/fetcher"
+	"github.com/vfunin/crawler/internal/retry"
+
+	"github.com/rs/zerolog"
+)
+
+// fetchWithRetries - fetches the url of the task repeating transient failures according to the retry policy,
+// returns the number of retries made
+func (c *crawler) fetchWithRetries(ctx context.Context, t task) (resp *fetcher.Response, retries int, err error) {
+	log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+
+	for {
+		resp, err = c.fetch(ctx, t)
+
+		if c.retry == nil || retries >= c.retry.MaxRetries() || ctx.Err() != nil || !retry.Transient(resp, err) {
+			return resp, retries, err
+		}
+
+		var retryAfter time.Duration
+		if resp != nil {
+			retryAfter = resp.RetryAfter
+		}
+
+		wait := c.retry.Backoff(retries, retryAfter)
+		retries++
+
+		log.Debug().Msgf("url %s failed transiently, retry %d in %s", t.url, retries, wait)
+
+		if err = retry.Sleep(ctx, wait); err != nil {
+			return nil, retries, err
+		}
+	}
+}
+
+// recordHealth - reports the outcome of a request to the host breaker
+func (c *crawler) recordHealth(ctx context.Context, host string, resp *fetcher.Response, err error) {
+	if c.breaker == nil || ctx.Err() != nil {
+		return
+	}
+
+	if !retry.Transient(resp, err) {
+		c.breaker.Success(host)
+
+		return
+	}
+
+	if until := c.breaker.Failure(host); !until.IsZero() {
+		log := ctx.Value(config.LoggerCtxKey).(zerolog.Logger)
+		log.Info().Msgf("host %s is failing, paused until %s", host, until.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,136 @@
+package fetcher
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vfunin/crawler/internal/parser"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const (
+	// prescanLen - bytes searched for a byte order mark and a meta charset declaration, the type of a response
+	// without the Content-Type header is detected from their beginning
+	prescanLen = 1024
+	// sniffLen - bytes of a page without a declared charset checked to be valid UTF-8
+	sniffLen = 64 * 1024
+)
+
+// read - parses the body of a successful response of a parsed type, bodies of other types are not downloaded
+// and at most the configured size of the others is read
+func (f *fetcher) read(method string, resp *http.Response, result *Response) (err error) {
+	if method == http.MethodHead {
+		result.ContentLength = resp.ContentLength
+
+		return nil
+	}
+
+	limited := &io.LimitedReader{R: resp.Body, N: f.maxBodySize()}
+	counter := &countingReader{reader: limited, n: 0}
+	body := bufio.NewReaderSize(counter, sniffLen)
+
+	if isSuccess(resp.StatusCode) {
+		// a read error is returned again by the next read
+		head, _ := body.Peek(prescanLen)
+
+		if result.ContentType == "" {
+			result.ContentType = http.DetectContentType(head)
+		}
+
+		if !f.parsed(result.ContentType) {
+			result.ContentLength = resp.ContentLength
+
+			return nil
+		}
+
+		// the byte order mark, the charset of the header and the meta declaration are checked in this order
+		enc, name, certain := charset.DetermineEncoding(head, result.ContentType)
+
+		// an undeclared ascii beginning is guessed as windows-1252, the page is still read as UTF-8 if it is valid
+		if !certain && name == "windows-1252" {
+			if sniffed, _ := body.Peek(sniffLen); validUTF8(sniffed, len(sniffed) == sniffLen) {
+				enc, name = unicode.UTF8, "utf-8"
+			}
+		}
+
+		result.Charset = name
+
+		if result.Page, err = parser.New(parser.WithRules(f.linkRules)).Parse(result.FinalURL, transform.NewReader(body, enc.NewDecoder())); err != nil {
+			return errors.Wrap(err, "parsing url")
+		}
+
+		result.Robots = result.Robots.Merge(result.Page.Robots())
+	}
+
+	if _, err = io.Copy(io.Discard, body); err != nil {
+		return errors.Wrap(err, "reading body")
+	}
+
+	result.ContentLength = counter.n
+	result.Truncated = limited.N == 0 && !exhausted(resp.Body)
+
+	return nil
+}
+
+// parsed - reports whether the body of the content type is parsed for links
+func (f *fetcher) parsed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	}
+
+	return f.content.Parsed(mediaType)
+}
+
+// binary - reports whether the url points to a binary file by its extension
+func (f *fetcher) binary(url string) bool {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return false
+	}
+
+	return f.content.Binary(u.Path)
+}
+
+// maxBodySize - returns the bytes read from a body, a non-positive limit reads everything
+func (f *fetcher) maxBodySize() int64 {
+	if f.content.MaxBodySize <= 0 {
+		return math.MaxInt64
+	}
+
+	return f.content.MaxBodySize
+}
+
+// validUTF8 - reports whether b is valid UTF-8, a rune cut off at the end of a partial body is ignored
+func validUTF8(b []byte, partial bool) bool {
+	if partial {
+		for start := len(b) - 1; start >= 0 && start >= len(b)-utf8.UTFMax; start-- {
+			if utf8.RuneStart(b[start]) {
+				if !utf8.FullRune(b[start:]) {
+					b = b[:start]
+				}
+
+				break
+			}
+		}
+	}
+
+	return utf8.Valid(b)
+}
+
+// exhausted - reports whether nothing is left to read from r
+func exhausted(r io.Reader) bool {
+	_, err := io.ReadFull(r, make([]byte, 1))
+
+	return err != nil
+}
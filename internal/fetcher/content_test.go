@@ -0,0 +1,189 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+)
+
+func newContentServer(gets, heads *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/file.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Length", "4096")
+
+		if r.Method == http.MethodHead {
+			atomic.AddInt32(heads, 1)
+
+			return
+		}
+
+		atomic.AddInt32(gets, 1)
+		_, _ = fmt.Fprint(w, strings.Repeat("%", 4096))
+	})
+	mux.HandleFunc("/page.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xhtml+xml; charset=utf-8")
+
+		if r.Method == http.MethodHead {
+			atomic.AddInt32(heads, 1)
+
+			return
+		}
+
+		atomic.AddInt32(gets, 1)
+		_, _ = fmt.Fprint(w, `<title>misnamed</title>`)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<title>large</title>`+strings.Repeat(" ", 1000))
+	})
+	mux.HandleFunc("/untyped", func(w http.ResponseWriter, r *http.Request) {
+		// keeps the server from detecting the type itself
+		w.Header()["Content-Type"] = nil
+		_, _ = fmt.Fprint(w, `<html><title>untyped</title></html>`)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func Test_fetcher_FetchContent(t *testing.T) {
+	var gets, heads int32
+
+	server := newContentServer(&gets, &heads)
+	defer server.Close()
+
+	cfg := config.DefaultContent()
+	cfg.MaxBodySize = 100
+	f := New(time.Second, WithContent(cfg))
+	ctx := context.Background()
+
+	resp, err := f.Fetch(ctx, server.URL+"/file.pdf")
+	assert.Nil(t, err)
+	assert.Nil(t, resp.Page)
+	assert.Equal(t, "application/pdf", resp.ContentType)
+	assert.Equal(t, int64(4096), resp.ContentLength)
+	assert.False(t, resp.Truncated)
+
+	resp, err = f.Fetch(ctx, server.URL+"/large")
+	assert.Nil(t, err)
+	assert.Equal(t, "large", resp.Page.Title())
+	assert.Equal(t, int64(100), resp.ContentLength)
+	assert.True(t, resp.Truncated)
+
+	resp, err = f.Fetch(ctx, server.URL+"/untyped")
+	assert.Nil(t, err)
+	assert.Equal(t, "untyped", resp.Page.Title())
+	assert.Equal(t, "text/html; charset=utf-8", resp.ContentType)
+	assert.False(t, resp.Truncated)
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&heads))
+}
+
+func Test_fetcher_FetchHeadBinaries(t *testing.T) {
+	var gets, heads int32
+
+	server := newContentServer(&gets, &heads)
+	defer server.Close()
+
+	cfg := config.DefaultContent()
+	cfg.HeadBinaries = true
+	f := New(time.Second, WithContent(cfg))
+	ctx := context.Background()
+
+	resp, err := f.Fetch(ctx, server.URL+"/file.pdf")
+	assert.Nil(t, err)
+	assert.Nil(t, resp.Page)
+	assert.Equal(t, int64(4096), resp.ContentLength)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&heads))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&gets))
+
+	resp, err = f.Fetch(ctx, server.URL+"/page.pdf")
+	assert.Nil(t, err)
+	assert.Equal(t, "misnamed", resp.Page.Title())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&heads))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&gets))
+}
+
+func Test_fetcher_FetchCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantCharset string
+	}{
+		{
+			name:        "header",
+			contentType: "text/html; charset=windows-1251",
+			body:        []byte("<title>\xcf\xf0\xe8\xe2\xe5\xf2</title>"),
+			wantCharset: "windows-1251",
+		},
+		{
+			name:        "meta",
+			contentType: "text/html",
+			body:        []byte(`<meta http-equiv="Content-Type" content="text/html; charset=koi8-r">` + "<title>\xf0\xd2\xc9\xd7\xc5\xd4</title>"),
+			wantCharset: "koi8-r",
+		},
+		{
+			name:        "bom",
+			contentType: "text/html; charset=windows-1251",
+			body:        append([]byte("\xef\xbb\xbf"), "<title>Привет</title>"...),
+			wantCharset: "utf-8",
+		},
+		{
+			name:        "utf-8",
+			contentType: "text/html",
+			body:        []byte("<title>Привет</title>"),
+			wantCharset: "utf-8",
+		},
+		{
+			name:        "utf-8 after a long ascii head",
+			contentType: "text/html",
+			body:        []byte("<head><!--" + strings.Repeat("ascii ", 500) + "--><title>Привет</title>"),
+			wantCharset: "utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			resp, err := New(time.Second).Fetch(context.Background(), server.URL)
+			assert.Nil(t, err)
+			assert.Equal(t, "Привет", resp.Page.Title())
+			assert.Equal(t, tt.wantCharset, resp.Charset)
+		})
+	}
+}
+
+func Test_fetcher_FetchInvalidUTF8(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, "<head><!--"+strings.Repeat("ascii ", 500)+"--><title>caf\xe9</title>")
+	}))
+	defer server.Close()
+
+	resp, err := New(time.Second).Fetch(context.Background(), server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "café", resp.Page.Title())
+	assert.Equal(t, "windows-1252", resp.Charset)
+}
+
+func Test_validUTF8(t *testing.T) {
+	assert.True(t, validUTF8([]byte("Привет"), false))
+	assert.True(t, validUTF8([]byte("Привет")[:3], true), "the cut rune of a partial body is ignored")
+	assert.False(t, validUTF8([]byte("Привет")[:3], false))
+	assert.False(t, validUTF8([]byte("caf\xe9 "), true))
+}
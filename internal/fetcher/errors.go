@@ -0,0 +1,45 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+// Kinds of fetch failures
+const (
+	FailureTimeout    = "timeout"
+	FailureDNS        = "dns failure"
+	FailureConnection = "connection failure"
+	FailureTLS        = "tls failure"
+)
+
+// Describe - returns the kind of the fetch failure or the error message when the kind is unknown
+func Describe(err error) string {
+	var (
+		dnsErr     *net.DNSError
+		netErr     net.Error
+		opErr      *net.OpError
+		authErr    x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+		recordErr  tls.RecordHeaderError
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return FailureDNS
+	case errors.As(err, &authErr), errors.As(err, &hostErr), errors.As(err, &invalidErr), errors.As(err, &recordErr):
+		return FailureTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return FailureTimeout
+	case errors.As(err, &opErr):
+		return FailureConnection
+	default:
+		return pkgerrors.Cause(err).Error()
+	}
+}
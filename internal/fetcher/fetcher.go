@@ -2,28 +2,241 @@ package fetcher
 
 import (
 	"context"
+	"crypto/tls"
+	"io"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/vfunin/crawler/internal/config"
 	"github.com/vfunin/crawler/internal/parser"
 
 	"github.com/pkg/errors"
+	"golang.org/x/net/publicsuffix"
 )
 
+const (
+	// keepAlive - interval of tcp keep-alive probes of open connections
+	keepAlive = 30 * time.Second
+	// maxRedirects - redirects followed for a single request, the same as the default of http.Client
+	maxRedirects = 10
+)
+
+// Response - fetched page with the details of the http exchange
+type Response struct {
+	// Page - parsed document, nil for unsuccessful responses and HEAD requests
+	Page       parser.Page
+	StatusCode int
+	// FinalURL - url of the document after redirects
+	FinalURL string
+	// Redirects - urls redirected from, in order, without the final url
+	Redirects     []string
+	ContentType   string
+	ContentLength int64
+	// ResponseTime - time from sending the request until the body is read
+	ResponseTime time.Duration
+	// RetryAfter - pause requested by the server with the Retry-After header, zero if absent
+	RetryAfter time.Duration
+	// Certificate - server certificate of the final url, nil for plain http
+	Certificate *Certificate
+	// Truncated - the body exceeds the size limit and is read only up to it
+	Truncated bool
+	// Charset - encoding the parsed body is decoded from, empty for bodies which are not parsed
+	Charset string
+	// Robots - directives of the X-Robots-Tag headers and the meta robots tags of the page
+	Robots parser.Directives
+	// LastModified - time of the Last-Modified header, zero if absent or malformed
+	LastModified time.Time
+}
+
 type Fetcher interface {
-	Fetch(ctx context.Context, url string) (page parser.Page, err error)
+	Fetch(ctx context.Context, url string) (resp *Response, err error)
+	Head(ctx context.Context, url string) (resp *Response, err error)
+	Login(ctx context.Context) error
+}
+
+type Option func(f *fetcher)
+
+// WithRoundTripper - sends all requests with rt, e.g. a transport returned by NewTransport
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return func(f *fetcher) {
+		f.client.Transport = rt
+	}
+}
+
+// WithUserAgent - sends userAgent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(f *fetcher) {
+		f.userAgent = userAgent
+	}
+}
+
+// WithRequest - sends the configured headers and credentials and keeps cookies if enabled,
+// environment variable references of cfg must be resolved
+func WithRequest(cfg config.Request) Option {
+	return func(f *fetcher) {
+		f.request = cfg
+
+		if cfg.Cookies {
+			f.keepCookies()
+		}
+	}
+}
+
+// WithLogin - logs in with the form before the first request and again when a request is redirected
+// to the login page, cookies are kept; environment variable references of cfg must be resolved
+func WithLogin(cfg config.Login) Option {
+	return func(f *fetcher) {
+		if !cfg.Enabled() {
+			return
+		}
+
+		// the login url is validated by the configuration
+		loginURL, _ := neturl.Parse(cfg.URL)
+
+		f.session = &session{mu: sync.Mutex{}, cfg: cfg, loginURL: loginURL, loggedIn: false, generation: 0}
+		f.keepCookies()
+	}
+}
+
+// WithContent - limits the downloaded bodies and selects the parsed responses
+func WithContent(cfg config.Content) Option {
+	return func(f *fetcher) {
+		f.content = cfg
+	}
+}
+
+// WithLinkRules - extracts the links of the parsed pages with rules instead of the default ones
+func WithLinkRules(rules []config.LinkRule) Option {
+	return func(f *fetcher) {
+		f.linkRules = rules
+	}
 }
 
 type fetcher struct {
-	timeout time.Duration
+	client    *http.Client
+	userAgent string
+	request   config.Request
+	session   *session
+	content   config.Content
+	linkRules []config.LinkRule
 }
 
-func New(timeout time.Duration) Fetcher {
-	return &fetcher{timeout: timeout}
+// New - returns a fetcher with a single client reused by all requests, timeout limits a whole request
+func New(timeout time.Duration, opts ...Option) Fetcher {
+	f := &fetcher{
+		client: &http.Client{ //nolint:exhaustivestruct
+			Transport: NewTransport(config.DefaultTransport()),
+			Timeout:   timeout,
+		},
+		userAgent: "",
+		request:   config.Request{Headers: nil, Cookies: false, Hosts: nil},
+		session:   nil,
+		content:   config.DefaultContent(),
+		linkRules: config.DefaultLinkRules(),
+	}
+	f.client.CheckRedirect = f.redirect
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
 }
 
-// Fetch - makes a request for a link and returns a parser.page with title and a slice of links on the page
-func (f *fetcher) Fetch(ctx context.Context, url string) (page parser.Page, err error) {
+// NewTransport - returns a pooling transport configured by cfg, proxies are taken from the environment
+func NewTransport(cfg config.Transport) *http.Transport {
+	dialer := &net.Dialer{ //nolint:exhaustivestruct
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: keepAlive,
+	}
+
+	t := &http.Transport{ //nolint:exhaustivestruct
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	if cfg.DisableHTTP2 {
+		// a non-nil empty map turns off the automatic HTTP/2 upgrade
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	return t
+}
+
+// Fetch - makes a request for a link and returns the response with a parser.page with title and a slice of links on the page,
+// urls of binary files are checked with HEAD first if configured and downloaded only when they turn out to be parsed
+func (f *fetcher) Fetch(ctx context.Context, url string) (result *Response, err error) {
+	if f.content.HeadBinaries && f.binary(url) {
+		result, err = f.Head(ctx, url)
+		if err != nil || !isSuccess(result.StatusCode) || result.Page != nil || !f.parsed(result.ContentType) {
+			return result, err
+		}
+	}
+
+	return f.do(ctx, http.MethodGet, url)
+}
+
+// Head - checks a link without downloading the body, falls back to GET when the server does not support HEAD
+func (f *fetcher) Head(ctx context.Context, url string) (result *Response, err error) {
+	if result, err = f.do(ctx, http.MethodHead, url); err != nil {
+		return nil, err
+	}
+
+	if result.StatusCode == http.StatusMethodNotAllowed || result.StatusCode == http.StatusNotImplemented {
+		return f.do(ctx, http.MethodGet, url)
+	}
+
+	return result, nil
+}
+
+// Login - logs in with the configured form unless it is already done, does nothing without the login settings
+func (f *fetcher) Login(ctx context.Context) error {
+	if f.session == nil {
+		return nil
+	}
+
+	_, err := f.session.ensure(ctx, f)
+
+	return errors.Wrap(err, "login")
+}
+
+// do - sends the request within the login session, a request redirected to the login page is repeated
+// once after a new login
+func (f *fetcher) do(ctx context.Context, method, url string) (result *Response, err error) {
+	if f.session == nil {
+		return f.send(ctx, method, url)
+	}
+
+	generation, err := f.session.ensure(ctx, f)
+	if err != nil {
+		return nil, errors.Wrap(err, "login")
+	}
+
+	if result, err = f.send(ctx, method, url); err != nil || !f.session.loggedOut(url, result) {
+		return result, err
+	}
+
+	if err = f.session.relogin(ctx, f, generation); err != nil {
+		return nil, errors.Wrap(err, "login")
+	}
+
+	return f.send(ctx, method, url)
+}
+
+func (f *fetcher) send(ctx context.Context, method, url string) (result *Response, err error) {
 	var (
 		resp *http.Response
 		req  *http.Request
@@ -31,31 +244,182 @@ func (f *fetcher) Fetch(ctx context.Context, url string) (page parser.Page, err
 
 	select {
 	case <-ctx.Done():
-		return
+		return nil, ctx.Err()
 	default:
-		client := &http.Client{ //nolint:exhaustivestruct
-			Timeout: f.timeout,
-		}
-		req, err = http.NewRequestWithContext(ctx, "GET", url, nil)
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
 
 		if err != nil {
 			return nil, errors.Wrap(err, "request")
 		}
 
-		resp, err = client.Do(req)
+		f.prepare(req)
+
+		start := time.Now()
+		resp, err = f.client.Do(req)
 
 		if err != nil {
 			return nil, errors.Wrap(err, "response")
 		}
 		defer resp.Body.Close()
 
-		page = parser.New()
-		page, err = page.Parse(url, resp.Body)
+		result = &Response{
+			Page:          nil,
+			StatusCode:    resp.StatusCode,
+			FinalURL:      resp.Request.URL.String(),
+			Redirects:     redirects(resp),
+			ContentType:   resp.Header.Get("Content-Type"),
+			ContentLength: 0,
+			ResponseTime:  0,
+			RetryAfter:    retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Certificate:   certificate(resp.TLS),
+			Truncated:     false,
+			Charset:       "",
+			Robots:        robotsTag(resp.Header.Values("X-Robots-Tag"), f.userAgent),
+			LastModified:  lastModified(resp.Header.Get("Last-Modified")),
+		}
 
-		if err != nil {
-			return nil, errors.Wrap(err, "parsing url")
+		if err = f.read(method, resp, result); err != nil {
+			return nil, err
 		}
 
+		result.ResponseTime = time.Since(start)
+
+		return result, nil
+	}
+}
+
+// keepCookies - enables the cookie jar shared by all requests
+func (f *fetcher) keepCookies() {
+	if f.client.Jar != nil {
 		return
 	}
+
+	// cookiejar.New never fails with options
+	f.client.Jar, _ = cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+}
+
+// prepare - sets the user agent, the headers and the credentials configured for the request host
+func (f *fetcher) prepare(req *http.Request) {
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+
+	rules := f.request.ForHost(req.URL.Host)
+
+	for name, value := range rules.Headers {
+		req.Header.Set(name, value)
+	}
+
+	switch {
+	case rules.Auth.Token != "":
+		req.Header.Set("Authorization", "Bearer "+rules.Auth.Token)
+	case rules.Auth.Username != "":
+		req.SetBasicAuth(rules.Auth.Username, rules.Auth.Password)
+	}
+}
+
+// redirect - replaces the headers copied from the previous request with the ones configured for the new host,
+// so credentials are never sent to another host
+func (f *fetcher) redirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("stopped after " + strconv.Itoa(maxRedirects) + " redirects")
+	}
+
+	referer := req.Header.Get("Referer")
+	req.Header = make(http.Header)
+
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+
+	f.prepare(req)
+
+	return nil
+}
+
+// redirects - returns the urls the response has been redirected from
+func redirects(resp *http.Response) (urls []string) {
+	for r := resp.Request; r != nil && r.Response != nil; r = r.Response.Request {
+		urls = append([]string{r.Response.Request.URL.String()}, urls...)
+	}
+
+	return urls
+}
+
+// retryAfter - parses the Retry-After header given in seconds or as an http date
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+// lastModified - parses the Last-Modified header value, zero is returned if it is absent or malformed
+func lastModified(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return date
+}
+
+// robotsTag - returns the directives of the X-Robots-Tag header values, values prefixed with a user agent name
+// (e.g. "googlebot: noindex") apply only if it is the product name of userAgent
+func robotsTag(values []string, userAgent string) (d parser.Directives) {
+	product := userAgent
+	if i := strings.IndexAny(userAgent, "/ "); i >= 0 {
+		product = userAgent[:i]
+	}
+
+	for _, value := range values {
+		if i := strings.Index(value, ":"); i >= 0 {
+			name := strings.TrimSpace(value[:i])
+
+			if name != "" && !strings.ContainsAny(name, ", ") && !strings.EqualFold(name, "unavailable_after") {
+				if !strings.EqualFold(name, product) {
+					continue
+				}
+
+				value = value[i+1:]
+			}
+		}
+
+		d = d.Merge(parser.ParseDirectives(value))
+	}
+
+	return d
+}
+
+func isSuccess(code int) bool {
+	return code >= http.StatusOK && code < http.StatusMultipleChoices
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.n += int64(n)
+
+	return n, err
 }
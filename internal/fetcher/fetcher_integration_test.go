@@ -51,8 +51,10 @@ func Test_fetcher_Fetch(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	f := New(time.Duration(100) * time.Second)
-	p, err := f.Fetch(ctx, url)
+	resp, err := f.Fetch(ctx, url)
 	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, url, resp.FinalURL)
 
 	var r *os.File
 
@@ -64,7 +66,7 @@ func Test_fetcher_Fetch(t *testing.T) {
 	page, err = page.Parse(url, bufio.NewReader(r))
 
 	assert.Nil(t, err)
-	assert.Equal(t, page, p)
+	assert.Equal(t, page, resp.Page)
 
 	err = server.Shutdown(ctx)
 
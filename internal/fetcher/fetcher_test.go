@@ -1,13 +1,267 @@
 package fetcher
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/parser"
 )
 
 func TestNew(t *testing.T) {
 	f := New(time.Duration(10))
 	assert.NotNil(t, f, "new fetcher is nil")
 }
+
+func Test_fetcher_FetchRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/first", http.RedirectHandler("/second", http.StatusFound))
+	mux.Handle("/second", http.RedirectHandler("/page", http.StatusMovedPermanently))
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprint(w, `<title>page</title>`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := New(time.Second).Fetch(context.Background(), server.URL+"/first")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, server.URL+"/page", resp.FinalURL)
+	assert.Equal(t, []string{server.URL + "/first", server.URL + "/second"}, resp.Redirects)
+	assert.Equal(t, "text/html; charset=utf-8", resp.ContentType)
+	assert.Equal(t, int64(len(`<title>page</title>`)), resp.ContentLength)
+	assert.Equal(t, "page", resp.Page.Title())
+}
+
+func Test_fetcher_FetchNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, `<title>not found</title>`)
+	}))
+	defer server.Close()
+
+	resp, err := New(time.Second).Fetch(context.Background(), server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Nil(t, resp.Page)
+	assert.Empty(t, resp.Redirects)
+	assert.Equal(t, int64(len(`<title>not found</title>`)), resp.ContentLength)
+}
+
+func Test_fetcher_Head(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `<title>page</title>`)
+	}))
+	defer server.Close()
+
+	resp, err := New(time.Second).Head(context.Background(), server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestDescribe(t *testing.T) {
+	_, err := New(time.Second).Fetch(context.Background(), "http://nonexistent.invalid/")
+	assert.Equal(t, FailureDNS, Describe(err))
+
+	_, err = New(time.Second).Fetch(context.Background(), "http://127.0.0.1:1/")
+	assert.Equal(t, FailureConnection, Describe(err))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	_, err = New(10*time.Millisecond).Fetch(context.Background(), server.URL)
+	assert.Equal(t, FailureTimeout, Describe(err))
+
+	assert.Equal(t, "unexpected", Describe(errors.New("unexpected")))
+}
+
+func TestNewTransport(t *testing.T) {
+	cfg := config.DefaultTransport()
+	tr := NewTransport(cfg)
+	assert.True(t, tr.ForceAttemptHTTP2)
+	assert.Nil(t, tr.TLSNextProto)
+	assert.Equal(t, cfg.MaxIdleConnsPerHost, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, cfg.IdleConnTimeout, tr.IdleConnTimeout)
+
+	cfg.DisableHTTP2 = true
+	cfg.ResponseHeaderTimeout = time.Second
+	tr = NewTransport(cfg)
+	assert.False(t, tr.ForceAttemptHTTP2)
+	assert.NotNil(t, tr.TLSNextProto)
+	assert.Empty(t, tr.TLSNextProto)
+	assert.Equal(t, time.Second, tr.ResponseHeaderTimeout)
+}
+
+func Test_fetcher_FetchReusesConnections(t *testing.T) {
+	var conns int32
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<title>page</title>`)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+
+	defer server.Close()
+
+	f := New(time.Second)
+
+	for i := 0; i < 5; i++ {
+		_, err := f.Fetch(context.Background(), server.URL)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+}
+
+// BenchmarkFetch - compares a fetcher shared by all requests with a fetcher created for every request
+func BenchmarkFetch(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<title>page</title><a href="/a">a</a>`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	b.Run("shared", func(b *testing.B) {
+		f := New(time.Second)
+
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := f.Fetch(ctx, server.URL); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+
+	b.Run("per request", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				f := New(time.Second, WithRoundTripper(NewTransport(config.DefaultTransport())))
+				if _, err := f.Fetch(ctx, server.URL); err != nil {
+					b.Error(err)
+				}
+
+				f.(*fetcher).client.CloseIdleConnections()
+			}
+		})
+	})
+}
+
+func Test_retryAfter(t *testing.T) {
+	now := time.Date(2021, 10, 21, 7, 28, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), retryAfter("", now))
+	assert.Equal(t, 120*time.Second, retryAfter("120", now))
+	assert.Equal(t, time.Duration(0), retryAfter("-1", now))
+	assert.Equal(t, 30*time.Second, retryAfter("Thu, 21 Oct 2021 07:28:30 GMT", now))
+	assert.Equal(t, time.Duration(0), retryAfter("Wed, 20 Oct 2021 07:28:00 GMT", now))
+	assert.Equal(t, time.Duration(0), retryAfter("soon", now))
+}
+
+func Test_lastModified(t *testing.T) {
+	assert.True(t, lastModified("").IsZero())
+	assert.True(t, lastModified("yesterday").IsZero())
+	assert.Equal(t, time.Date(2021, 10, 21, 7, 28, 0, 0, time.UTC), lastModified("Thu, 21 Oct 2021 07:28:00 GMT").UTC())
+}
+
+func Test_robotsTag(t *testing.T) {
+	ua := "crawler/1.0 (+https://github.com/vfunin/crawler)"
+
+	assert.Equal(t, parser.Directives{NoIndex: false, NoFollow: false}, robotsTag(nil, ua))
+	assert.Equal(t, parser.Directives{NoIndex: true, NoFollow: true}, robotsTag([]string{"noindex", "nofollow"}, ua))
+	assert.Equal(t, parser.Directives{NoIndex: false, NoFollow: false}, robotsTag([]string{"googlebot: noindex"}, ua))
+	assert.Equal(t, parser.Directives{NoIndex: true, NoFollow: false}, robotsTag([]string{"Crawler: noindex"}, ua))
+	assert.Equal(t, parser.Directives{NoIndex: true, NoFollow: false},
+		robotsTag([]string{"noindex, unavailable_after: 25 Jun 2010 15:00:00 PST"}, ""))
+}
+
+func Test_fetcher_FetchRobots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Robots-Tag", "noindex")
+		_, _ = fmt.Fprint(w, `<meta name="robots" content="nofollow"><link rel="canonical" href="/page">`)
+	}))
+	defer server.Close()
+
+	resp, err := New(time.Second).Fetch(context.Background(), server.URL+"/page?from=feed")
+	assert.Nil(t, err)
+	assert.Equal(t, parser.Directives{NoIndex: true, NoFollow: true}, resp.Robots)
+	assert.Equal(t, server.URL+"/page", resp.Page.Canonical())
+}
+
+func Test_fetcher_FetchRequestSettings(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("X-Host"))
+		assert.Equal(t, "global", r.Header.Get("X-Global"))
+	}))
+	defer other.Close()
+
+	// the same server under another host name so the host rules differ
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "42"}) //nolint:exhaustivestruct
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "admin", user)
+		assert.Equal(t, "secret", password)
+		assert.Equal(t, "test-bot/1.0", r.UserAgent())
+		assert.Equal(t, "global", r.Header.Get("X-Global"))
+		assert.Equal(t, "host", r.Header.Get("X-Host"))
+
+		cookie, err := r.Cookie("session")
+		assert.Nil(t, err)
+		assert.Equal(t, "42", cookie.Value)
+
+		http.Redirect(w, r, otherURL, http.StatusFound)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f := New(time.Second, WithUserAgent("test-bot/1.0"), WithRequest(config.Request{
+		Headers: map[string]string{"X-Global": "global"},
+		Cookies: true,
+		Hosts: map[string]config.HostRequest{
+			"127.0.0.1": {
+				Headers: map[string]string{"X-Host": "host"},
+				Auth:    config.Auth{Username: "admin", Password: "secret", Token: ""},
+			},
+		},
+	}))
+
+	_, err := f.Fetch(context.Background(), server.URL+"/login")
+	assert.Nil(t, err)
+
+	resp, err := f.Fetch(context.Background(), server.URL+"/page")
+	assert.Nil(t, err)
+	assert.Equal(t, otherURL, resp.FinalURL)
+}
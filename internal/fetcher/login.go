@@ -0,0 +1,264 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/vfunin/crawler/internal/config"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pkg/errors"
+)
+
+// session - state of the form login shared by all requests of the fetcher
+type session struct {
+	mu       sync.Mutex
+	cfg      config.Login
+	loginURL *url.URL
+	loggedIn bool
+	// generation - number of performed logins, used to re-login once when concurrent requests detect the logout
+	generation uint64
+}
+
+// ensure - logs in unless the session is already logged in and returns the login generation
+func (s *session) ensure(ctx context.Context, f *fetcher) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loggedIn {
+		return s.generation, nil
+	}
+
+	if err := s.login(ctx, f); err != nil {
+		return s.generation, err
+	}
+
+	s.loggedIn = true
+	s.generation++
+
+	return s.generation, nil
+}
+
+// relogin - logs in again unless another request has already done it after generation
+func (s *session) relogin(ctx context.Context, f *fetcher, generation uint64) error {
+	s.mu.Lock()
+	if s.generation == generation {
+		s.loggedIn = false
+	}
+	s.mu.Unlock()
+
+	_, err := s.ensure(ctx, f)
+
+	return err
+}
+
+// loggedOut - reports whether a request to requested ended up on the login page
+func (s *session) loggedOut(requested string, resp *Response) bool {
+	if s.isLoginPage(requested) {
+		return false
+	}
+
+	if s.isLoginPage(resp.FinalURL) {
+		return true
+	}
+
+	for _, r := range resp.Redirects {
+		if s.isLoginPage(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *session) isLoginPage(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, s.loginURL.Host) && strings.TrimSuffix(u.Path, "/") == strings.TrimSuffix(s.loginURL.Path, "/")
+}
+
+// loginForm - the login form found on the login page
+type loginForm struct {
+	action *url.URL
+	method string
+	values url.Values
+}
+
+// login - submits the login form with the configured fields and checks the result
+func (s *session) login(ctx context.Context, f *fetcher) error {
+	form, err := s.form(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range s.cfg.Fields {
+		form.values.Set(name, value)
+	}
+
+	req, err := form.request(ctx)
+	if err != nil {
+		return errors.Wrap(err, "login request")
+	}
+
+	f.prepare(req)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "login response")
+	}
+	defer resp.Body.Close()
+
+	return s.check(f, resp)
+}
+
+// form - loads the login page and returns the form with the values of its fields
+func (s *session) form(ctx context.Context, f *fetcher) (*loginForm, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.URL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "login page request")
+	}
+
+	f.prepare(req)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "login page response")
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "login page parsing")
+	}
+
+	selector := s.cfg.Form
+	if selector == "" {
+		selector = config.DefaultLoginForm
+	}
+
+	form := doc.Find(selector).First()
+	if form.Length() == 0 {
+		return nil, errors.New("login form " + selector + " is not found on " + s.cfg.URL)
+	}
+
+	action := resp.Request.URL
+	if href, ok := form.Attr("action"); ok && strings.TrimSpace(href) != "" {
+		ref, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			return nil, errors.Wrap(err, "login form action parsing")
+		}
+
+		action = action.ResolveReference(ref)
+	}
+
+	method := http.MethodPost
+	if strings.EqualFold(strings.TrimSpace(form.AttrOr("method", "")), http.MethodGet) {
+		method = http.MethodGet
+	}
+
+	return &loginForm{action: action, method: method, values: formValues(form)}, nil
+}
+
+// formValues - returns the values of the controls submitted with the form: enabled inputs except buttons and files,
+// checked checkboxes and radio buttons, textareas and selected options
+func formValues(form *goquery.Selection) url.Values {
+	values := url.Values{}
+
+	form.Find("input[name], textarea[name], select[name]").Each(func(_ int, control *goquery.Selection) {
+		if _, ok := control.Attr("disabled"); ok || control.Closest("fieldset[disabled]").Length() > 0 {
+			return
+		}
+
+		name, _ := control.Attr("name")
+
+		switch goquery.NodeName(control) {
+		case "textarea":
+			values.Add(name, control.Text())
+		case "select":
+			options := control.Find("option:checked")
+			if options.Length() == 0 && control.AttrOr("multiple", "-") == "-" {
+				options = control.Find("option").First()
+			}
+
+			options.Each(func(_ int, option *goquery.Selection) {
+				values.Add(name, option.AttrOr("value", strings.TrimSpace(option.Text())))
+			})
+		default:
+			switch strings.ToLower(control.AttrOr("type", "text")) {
+			case "submit", "button", "image", "reset", "file":
+				return
+			case "checkbox", "radio":
+				if _, ok := control.Attr("checked"); !ok {
+					return
+				}
+
+				values.Add(name, control.AttrOr("value", "on"))
+			default:
+				values.Add(name, control.AttrOr("value", ""))
+			}
+		}
+	})
+
+	return values
+}
+
+// request - returns the submission of the form, the values are sent in the query of get forms
+// and in the urlencoded body of post forms
+func (f *loginForm) request(ctx context.Context) (*http.Request, error) {
+	if f.method == http.MethodGet {
+		action := *f.action
+		action.RawQuery = f.values.Encode()
+
+		return http.NewRequestWithContext(ctx, http.MethodGet, action.String(), nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.action.String(), strings.NewReader(f.values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+// check - reports an error unless the response to the submitted form meets the success conditions,
+// without conditions the login succeeds when the response is not an error and not the login page
+func (s *session) check(f *fetcher, resp *http.Response) error {
+	finalURL := resp.Request.URL.String()
+
+	switch {
+	case s.cfg.SuccessStatus != 0 && resp.StatusCode != s.cfg.SuccessStatus:
+		return errors.Errorf("login failed: status %d", resp.StatusCode)
+	case s.cfg.SuccessURL != "" && !strings.Contains(finalURL, s.cfg.SuccessURL):
+		return errors.Errorf("login failed: redirected to %s", finalURL)
+	case s.cfg.SuccessCookie != "" && !hasCookie(f.client.Jar, s.loginURL, s.cfg.SuccessCookie):
+		return errors.Errorf("login failed: cookie %s is not set", s.cfg.SuccessCookie)
+	case s.cfg.SuccessStatus == 0 && resp.StatusCode >= http.StatusBadRequest:
+		return errors.Errorf("login failed: status %d", resp.StatusCode)
+	case s.cfg.SuccessURL == "" && s.cfg.SuccessCookie == "" && s.isLoginPage(finalURL):
+		return errors.Errorf("login failed: redirected to the login page %s", finalURL)
+	default:
+		return nil
+	}
+}
+
+func hasCookie(jar http.CookieJar, u *url.URL, name string) bool {
+	if jar == nil {
+		return false
+	}
+
+	for _, cookie := range jar.Cookies(u) {
+		if cookie.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
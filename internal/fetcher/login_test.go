@@ -0,0 +1,158 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+)
+
+// newApp - returns a server with a login form protected by a csrf token and a page available only after the login
+func newApp(t *testing.T, logins *int32) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = fmt.Fprint(w, `<form id="search"></form>
+<form id="login" action="/session" method="post">
+	<input type="hidden" name="csrf" value="token">
+	<input name="user"><input type="password" name="password">
+	<input type="checkbox" name="remember" value="1">
+	<select name="realm"><option value="staff">Staff</option><option selected>customers</option></select>
+	<select name="lang"><option value="en">English</option><option value="de">German</option></select>
+	<textarea name="note">remote</textarea>
+	<input name="legacy" value="1" disabled>
+	<fieldset disabled><input name="otp" value="000"></fieldset>
+	<input type="submit" name="go" value="Sign in">
+</form>`)
+
+			return
+		}
+	})
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		assert.Empty(t, r.PostForm.Get("go"))
+		assert.Empty(t, r.PostForm.Get("remember"))
+		assert.Equal(t, "customers", r.PostForm.Get("realm"))
+		assert.Equal(t, "en", r.PostForm.Get("lang"), "the first option of a select without a selected one is sent")
+		assert.Equal(t, "remote", r.PostForm.Get("note"))
+		assert.NotContains(t, r.PostForm, "legacy")
+		assert.NotContains(t, r.PostForm, "otp")
+
+		if r.PostForm.Get("csrf") != "token" || r.PostForm.Get("user") != "admin" || r.PostForm.Get("password") != "secret" {
+			http.Redirect(w, r, "/login?error=1", http.StatusFound)
+
+			return
+		}
+
+		atomic.AddInt32(logins, 1)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "ok", Path: "/"}) //nolint:exhaustivestruct
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "", Path: "/", MaxAge: -1}) //nolint:exhaustivestruct
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err != nil || c.Value != "ok" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `<title>%s</title>`, r.URL.Path)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func Test_fetcher_Login(t *testing.T) {
+	var logins int32
+
+	server := newApp(t, &logins)
+	defer server.Close()
+
+	ctx := context.Background()
+	f := New(time.Second, WithLogin(config.Login{
+		URL:           server.URL + "/login",
+		Form:          "form#login",
+		Fields:        map[string]string{"user": "admin", "password": "secret"},
+		SuccessStatus: http.StatusOK,
+		SuccessURL:    "/home",
+		SuccessCookie: "session",
+	}))
+
+	assert.Nil(t, f.Login(ctx))
+	assert.Nil(t, f.Login(ctx))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&logins))
+
+	resp, err := f.Fetch(ctx, server.URL+"/private")
+	assert.Nil(t, err)
+	assert.Equal(t, "/private", resp.Page.Title())
+
+	_, err = f.Fetch(ctx, server.URL+"/logout")
+	assert.Nil(t, err)
+
+	resp, err = f.Fetch(ctx, server.URL+"/private")
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/private", resp.FinalURL)
+	assert.Equal(t, "/private", resp.Page.Title())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&logins))
+}
+
+func Test_fetcher_LoginFailed(t *testing.T) {
+	var logins int32
+
+	server := newApp(t, &logins)
+	defer server.Close()
+
+	f := New(time.Second, WithLogin(config.Login{
+		URL:           server.URL + "/login",
+		Form:          "form#login",
+		Fields:        map[string]string{"user": "admin", "password": "wrong"},
+		SuccessStatus: 0,
+		SuccessURL:    "",
+		SuccessCookie: "",
+	}))
+
+	assert.EqualError(t, f.Login(context.Background()),
+		"login: login failed: redirected to the login page "+server.URL+"/login?error=1")
+
+	_, err := f.Fetch(context.Background(), server.URL+"/private")
+	assert.Error(t, err)
+
+	f = New(time.Second, WithLogin(config.Login{ //nolint:exhaustivestruct
+		URL:  server.URL + "/login",
+		Form: "form#missing",
+	}))
+	assert.Error(t, f.Login(context.Background()))
+}
+
+func Test_fetcher_LoginGet(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<form action="/session?stale=1" method="GET"><input name="user"><input name="token" value="t"></form>`)
+	})
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "token=t&user=admin", r.URL.RawQuery, "the form values replace the query of the action")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "ok", Path: "/"}) //nolint:exhaustivestruct
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f := New(time.Second, WithLogin(config.Login{ //nolint:exhaustivestruct
+		URL:           server.URL + "/login",
+		Fields:        map[string]string{"user": "admin"},
+		SuccessCookie: "session",
+	}))
+
+	assert.Nil(t, f.Login(context.Background()))
+}
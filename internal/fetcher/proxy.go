@@ -0,0 +1,152 @@
+package fetcher
+
+import (
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/vfunin/crawler/internal/config"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyFunc - returns the proxy of the request, nil for a direct connection
+type ProxyFunc func(req *http.Request) (*neturl.URL, error)
+
+// route - returns the proxy of the url, nil for a direct connection
+type route func(u *neturl.URL) (*neturl.URL, error)
+
+type rotation struct {
+	// next - index of the next route, kept first for the 64-bit alignment of atomic operations
+	next       uint64
+	routes     []route
+	roundRobin bool
+}
+
+func (r *rotation) route(u *neturl.URL) (*neturl.URL, error) {
+	if !r.roundRobin || len(r.routes) == 1 {
+		return r.routes[0](u)
+	}
+
+	n := atomic.AddUint64(&r.next, 1) - 1
+
+	return r.routes[n%uint64(len(r.routes))](u)
+}
+
+type proxyRule struct {
+	hosts    []string
+	rotation *rotation
+}
+
+type proxy struct {
+	rules    []proxyRule
+	fallback *rotation
+}
+
+// NewProxy - returns the proxy selection configured by cfg: the first rule matching the host is used,
+// other hosts use the configured proxies unless NO_PROXY excludes them, or HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// if no proxies are configured; environment variable references of cfg must be resolved
+func NewProxy(cfg config.Proxy) (ProxyFunc, error) {
+	env := httpproxy.FromEnvironment()
+	p := &proxy{rules: nil, fallback: nil}
+
+	for _, rule := range cfg.Rules {
+		routes := make([]route, 0, len(rule.Proxies))
+
+		for _, value := range rule.Proxies {
+			r, err := fixedRoute(value)
+			if err != nil {
+				return nil, err
+			}
+
+			routes = append(routes, r)
+		}
+
+		hosts := make([]string, 0, len(rule.Hosts))
+		for _, host := range rule.Hosts {
+			hosts = append(hosts, strings.ToLower(host))
+		}
+
+		p.rules = append(p.rules, proxyRule{hosts: hosts, rotation: newRotation(routes, cfg.RoundRobin)})
+	}
+
+	if len(cfg.Proxies) == 0 {
+		p.fallback = newRotation([]route{env.ProxyFunc()}, false)
+
+		return p.proxy, nil
+	}
+
+	routes := make([]route, 0, len(cfg.Proxies))
+
+	for _, value := range cfg.Proxies {
+		if value == config.DirectProxy {
+			routes = append(routes, direct)
+
+			continue
+		}
+
+		if _, err := fixedRoute(value); err != nil {
+			return nil, err
+		}
+
+		// the configured proxy replaces the environment ones, the exclusions of NO_PROXY are kept
+		c := httpproxy.Config{HTTPProxy: value, HTTPSProxy: value, NoProxy: env.NoProxy, CGI: false}
+		routes = append(routes, c.ProxyFunc())
+	}
+
+	p.fallback = newRotation(routes, cfg.RoundRobin)
+
+	return p.proxy, nil
+}
+
+func (p *proxy) proxy(req *http.Request) (*neturl.URL, error) {
+	host := strings.ToLower(req.URL.Hostname())
+
+	for _, rule := range p.rules {
+		if matchHost(rule.hosts, host) {
+			return rule.rotation.route(req.URL)
+		}
+	}
+
+	return p.fallback.route(req.URL)
+}
+
+func newRotation(routes []route, roundRobin bool) *rotation {
+	return &rotation{next: 0, routes: routes, roundRobin: roundRobin}
+}
+
+// fixedRoute - returns the route always using the proxy of value
+func fixedRoute(value string) (route, error) {
+	if value == config.DirectProxy {
+		return direct, nil
+	}
+
+	u, err := neturl.Parse(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "proxy url parsing")
+	}
+
+	return func(*neturl.URL) (*neturl.URL, error) {
+		return u, nil
+	}, nil
+}
+
+func direct(*neturl.URL) (*neturl.URL, error) {
+	return nil, nil
+}
+
+// matchHost - reports whether host matches any of the patterns: a host name, "*.example.com" or "*"
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "*" || pattern == host:
+			return true
+		case strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]):
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+package fetcher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vfunin/crawler/internal/config"
+
+	"github.com/pkg/errors"
+)
+
+// Certificate - server certificate of a tls connection
+type Certificate struct {
+	Issuer  string
+	Expires time.Time
+}
+
+// hostTransports - sends the requests to the hosts with their own client certificate through separate transports
+type hostTransports struct {
+	hosts    map[string]*http.Transport
+	fallback *http.Transport
+}
+
+// ConfigureTLS - sets the trusted certificate authorities and the verification of cfg on t and returns
+// the round tripper sending the client certificates to their hosts, t is used for the hosts without one
+func ConfigureTLS(t *http.Transport, cfg config.TLS) (http.RoundTripper, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify} //nolint:exhaustivestruct,gosec
+
+	if len(cfg.CAFiles) > 0 {
+		pool, err := certPool(cfg.CAFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsCfg.RootCAs = pool
+	}
+
+	if cc, ok := cfg.ClientCerts[config.AllHosts]; ok {
+		cert, err := tls.LoadX509KeyPair(cc.Cert, cc.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "client certificate loading")
+		}
+
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	t.TLSClientConfig = tlsCfg
+
+	rt := &hostTransports{hosts: make(map[string]*http.Transport), fallback: t}
+
+	for host, cc := range cfg.ClientCerts {
+		if host == config.AllHosts {
+			continue
+		}
+
+		cert, err := tls.LoadX509KeyPair(cc.Cert, cc.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "client certificate loading of "+host)
+		}
+
+		ht := t.Clone()
+		ht.TLSClientConfig.Certificates = []tls.Certificate{cert}
+		rt.hosts[strings.ToLower(host)] = ht
+	}
+
+	if len(rt.hosts) == 0 {
+		return t, nil
+	}
+
+	return rt, nil
+}
+
+func (rt *hostTransports) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t, ok := rt.hosts[strings.ToLower(req.URL.Hostname())]; ok {
+		return t.RoundTrip(req)
+	}
+
+	return rt.fallback.RoundTrip(req)
+}
+
+// CloseIdleConnections - closes the idle connections of all transports, called by http.Client
+func (rt *hostTransports) CloseIdleConnections() {
+	for _, t := range rt.hosts {
+		t.CloseIdleConnections()
+	}
+
+	rt.fallback.CloseIdleConnections()
+}
+
+// certPool - returns the system certificate authorities with the ones of the pem files
+func certPool(files []string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "certificate authority reading")
+		}
+
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, errors.New("no certificates found in " + file)
+		}
+	}
+
+	return pool, nil
+}
+
+// certificate - returns the leaf certificate of the connection, nil for plain http
+func certificate(state *tls.ConnectionState) *Certificate {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+
+	issuer := leaf.Issuer.CommonName
+	if issuer == "" {
+		issuer = leaf.Issuer.String()
+	}
+
+	return &Certificate{Issuer: issuer, Expires: leaf.NotAfter}
+}
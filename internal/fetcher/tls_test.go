@@ -0,0 +1,140 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+)
+
+// writePEM - saves the pem block to a file of dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	assert.Nil(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+
+	return path
+}
+
+// clientCert - generates a self-signed client certificate and returns the paths of the certificate and the key
+func clientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{ //nolint:exhaustivestruct
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "crawler"}, //nolint:exhaustivestruct
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+
+	return writePEM(t, dir, "client.pem", "CERTIFICATE", der), writePEM(t, dir, "client.key", "PRIVATE KEY", keyDER)
+}
+
+func newTLSFetcher(t *testing.T, cfg config.TLS) Fetcher {
+	t.Helper()
+
+	rt, err := ConfigureTLS(NewTransport(config.DefaultTransport()), cfg)
+	assert.Nil(t, err)
+
+	return New(time.Second, WithRoundTripper(rt))
+}
+
+func TestConfigureTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `<title>secure</title>`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	ctx := context.Background()
+
+	_, err := New(time.Second).Fetch(ctx, server.URL)
+	assert.Equal(t, FailureTLS, Describe(err))
+
+	resp, err := newTLSFetcher(t, config.TLS{CAFiles: []string{caFile}}).Fetch(ctx, server.URL) //nolint:exhaustivestruct
+	assert.Nil(t, err)
+	assert.Equal(t, "secure", resp.Page.Title())
+	assert.NotNil(t, resp.Certificate)
+	assert.Equal(t, server.Certificate().NotAfter, resp.Certificate.Expires)
+	assert.NotEmpty(t, resp.Certificate.Issuer)
+
+	resp, err = newTLSFetcher(t, config.TLS{InsecureSkipVerify: true}).Fetch(ctx, server.URL) //nolint:exhaustivestruct
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = ConfigureTLS(NewTransport(config.DefaultTransport()), config.TLS{CAFiles: []string{filepath.Join(dir, "missing.pem")}}) //nolint:exhaustivestruct
+	assert.Error(t, err)
+
+	plain := filepath.Join(dir, "plain.pem")
+	assert.Nil(t, os.WriteFile(plain, []byte("not a certificate"), 0o600))
+
+	_, err = ConfigureTLS(NewTransport(config.DefaultTransport()), config.TLS{CAFiles: []string{plain}}) //nolint:exhaustivestruct
+	assert.Error(t, err)
+}
+
+func TestConfigureTLSClientCertificates(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert} //nolint:exhaustivestruct,gosec
+	server.StartTLS()
+
+	defer server.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := clientCert(t, dir)
+	ctx := context.Background()
+
+	// the client certificate of another host is not sent
+	_, err := newTLSFetcher(t, config.TLS{ //nolint:exhaustivestruct
+		InsecureSkipVerify: true,
+		ClientCerts:        map[string]config.ClientCert{"go.test": {Cert: certFile, Key: keyFile}},
+	}).Fetch(ctx, server.URL)
+	assert.Error(t, err)
+
+	resp, err := newTLSFetcher(t, config.TLS{ //nolint:exhaustivestruct
+		InsecureSkipVerify: true,
+		ClientCerts:        map[string]config.ClientCert{"127.0.0.1": {Cert: certFile, Key: keyFile}},
+	}).Head(ctx, server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = newTLSFetcher(t, config.TLS{ //nolint:exhaustivestruct
+		InsecureSkipVerify: true,
+		ClientCerts:        map[string]config.ClientCert{config.AllHosts: {Cert: certFile, Key: keyFile}},
+	}).Fetch(ctx, server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = ConfigureTLS(NewTransport(config.DefaultTransport()), config.TLS{ //nolint:exhaustivestruct
+		ClientCerts: map[string]config.ClientCert{"go.test": {Cert: certFile, Key: certFile}},
+	})
+	assert.Error(t, err)
+}
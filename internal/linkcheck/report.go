@@ -0,0 +1,151 @@
+package linkcheck
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/vfunin/crawler/internal/crawler"
+
+	"github.com/pkg/errors"
+)
+
+// NoReferrer - group of the broken urls which are not linked from any crawled page, e.g. seeds
+const NoReferrer = "(no referrer)"
+
+// Reference - link to a url from a crawled page
+type Reference struct {
+	Page   string
+	Anchor string
+}
+
+// Target - broken url with the pages linking to it
+type Target struct {
+	URL        string
+	StatusCode int
+	Error      string
+	References []Reference
+}
+
+type Report interface {
+	Add(r crawler.Result)
+	Broken() []Target
+	Write(w io.Writer) error
+}
+
+type report struct {
+	references map[string][]Reference
+	broken     map[string]crawler.Result
+}
+
+func New() Report {
+	return &report{
+		references: make(map[string][]Reference),
+		broken:     make(map[string]crawler.Result),
+	}
+}
+
+// IsBroken - reports whether the result is a fetch failure or a 4xx/5xx response
+func IsBroken(r crawler.Result) bool {
+	return r.Error != "" || r.StatusCode >= http.StatusBadRequest
+}
+
+// Add - remembers the links of the result page and the result itself if it is broken
+func (r *report) Add(res crawler.Result) {
+	for _, link := range res.Links {
+		r.references[link.URL] = append(r.references[link.URL], Reference{Page: res.URL, Anchor: link.Text})
+	}
+
+	if IsBroken(res) {
+		r.broken[res.URL] = res
+	}
+}
+
+// Broken - returns the broken urls sorted by url
+func (r *report) Broken() []Target {
+	targets := make([]Target, 0, len(r.broken))
+
+	for url, res := range r.broken {
+		targets = append(targets, Target{
+			URL:        url,
+			StatusCode: res.StatusCode,
+			Error:      res.Error,
+			References: r.references[url],
+		})
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].URL < targets[j].URL
+	})
+
+	return targets
+}
+
+// Write - outputs the broken urls grouped by the pages linking to them
+func (r *report) Write(w io.Writer) (err error) {
+	targets := r.Broken()
+	pages := make(map[string][]string)
+
+	for _, t := range targets {
+		if len(t.References) == 0 {
+			pages[NoReferrer] = append(pages[NoReferrer], line(t, ""))
+
+			continue
+		}
+
+		seen := make(map[Reference]struct{})
+
+		for _, ref := range t.References {
+			if _, ok := seen[ref]; ok {
+				continue
+			}
+
+			seen[ref] = struct{}{}
+			pages[ref.Page] = append(pages[ref.Page], line(t, ref.Anchor))
+		}
+	}
+
+	names := make([]string, 0, len(pages))
+	for page := range pages {
+		names = append(names, page)
+	}
+
+	sort.Strings(names)
+
+	for _, page := range names {
+		if _, err = fmt.Fprintln(w, page); err != nil {
+			return errors.Wrap(err, "report writing")
+		}
+
+		for _, l := range pages[page] {
+			if _, err = fmt.Fprintln(w, "\t"+l); err != nil {
+				return errors.Wrap(err, "report writing")
+			}
+		}
+	}
+
+	linking := len(names)
+	if _, ok := pages[NoReferrer]; ok {
+		linking--
+	}
+
+	_, err = fmt.Fprintf(w, "%d broken links found on %d pages\n", len(targets), linking)
+
+	return errors.Wrap(err, "report writing")
+}
+
+// line - formats the broken url with its status or failure and the anchor text
+func line(t Target, anchor string) string {
+	status := t.Error
+	if status == "" {
+		status = strconv.Itoa(t.StatusCode)
+	}
+
+	if anchor == "" {
+		return status + " " + t.URL
+	}
+
+	return status + " " + t.URL + " " + strconv.Quote(anchor)
+}
@@ -0,0 +1,61 @@
+package linkcheck
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/crawler"
+	"github.com/vfunin/crawler/internal/fetcher"
+	"github.com/vfunin/crawler/internal/parser"
+)
+
+func TestIsBroken(t *testing.T) {
+	assert.False(t, IsBroken(crawler.Result{StatusCode: 200}))
+	assert.False(t, IsBroken(crawler.Result{Skipped: "out of scope"}))
+	assert.True(t, IsBroken(crawler.Result{StatusCode: 404}))
+	assert.True(t, IsBroken(crawler.Result{StatusCode: 503}))
+	assert.True(t, IsBroken(crawler.Result{Error: fetcher.FailureDNS}))
+}
+
+func TestReport(t *testing.T) {
+	r := New()
+
+	r.Add(crawler.Result{
+		URL:        "http://go.test/",
+		StatusCode: 200,
+		Links: []parser.Link{
+			{URL: "http://go.test/a", Text: "A"},
+			{URL: "http://go.test/missing", Text: "Missing"},
+			{URL: "http://go.test/missing", Text: "Missing"},
+		},
+	})
+	r.Add(crawler.Result{URL: "http://go.test/missing", StatusCode: 404})
+	r.Add(crawler.Result{URL: "http://dead.test/", Error: fetcher.FailureTimeout})
+	r.Add(crawler.Result{
+		URL:        "http://go.test/a",
+		StatusCode: 200,
+		Links: []parser.Link{
+			{URL: "http://go.test/missing", Text: ""},
+			{URL: "http://dead.test/", Text: "Dead"},
+		},
+	})
+
+	broken := r.Broken()
+	assert.Len(t, broken, 2)
+	assert.Equal(t, "http://dead.test/", broken[0].URL)
+	assert.Equal(t, []Reference{{Page: "http://go.test/a", Anchor: "Dead"}}, broken[0].References)
+	assert.Equal(t, 404, broken[1].StatusCode)
+	assert.Len(t, broken[1].References, 3)
+
+	var buf bytes.Buffer
+
+	assert.Nil(t, r.Write(&buf))
+	assert.Equal(t, `http://go.test/
+	404 http://go.test/missing "Missing"
+http://go.test/a
+	timeout http://dead.test/ "Dead"
+	404 http://go.test/missing
+2 broken links found on 2 pages
+`, buf.String())
+}
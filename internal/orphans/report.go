@@ -0,0 +1,77 @@
+package orphans
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/vfunin/crawler/internal/crawler"
+
+	"github.com/pkg/errors"
+)
+
+// Orphan - url listed in a sitemap or a feed which no crawled page links to
+type Orphan struct {
+	URL string
+	// Source - url of the sitemap or the feed listing the url
+	Source string
+}
+
+type Report interface {
+	Add(r crawler.Result)
+	Orphans() []Orphan
+	Write(w io.Writer) error
+}
+
+type report struct {
+	listed map[string]string
+	linked map[string]struct{}
+}
+
+// New - returns the report of the sitemap urls which are not linked from the crawled pages
+func New() Report {
+	return &report{listed: make(map[string]string), linked: make(map[string]struct{})}
+}
+
+// Add - remembers the url if it comes from a sitemap and the links of its page
+func (r *report) Add(res crawler.Result) {
+	if res.Source != "" {
+		r.listed[res.URL] = res.Source
+	}
+
+	for _, link := range res.Links {
+		r.linked[link.URL] = struct{}{}
+	}
+}
+
+// Orphans - returns the listed urls without links to them sorted by url
+func (r *report) Orphans() []Orphan {
+	orphans := make([]Orphan, 0)
+
+	for u, source := range r.listed {
+		if _, ok := r.linked[u]; !ok {
+			orphans = append(orphans, Orphan{URL: u, Source: source})
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].URL < orphans[j].URL
+	})
+
+	return orphans
+}
+
+// Write - outputs the orphan urls with the sitemaps listing them
+func (r *report) Write(w io.Writer) (err error) {
+	orphans := r.Orphans()
+
+	for _, o := range orphans {
+		if _, err = fmt.Fprintf(w, "%s (%s)\n", o.URL, o.Source); err != nil {
+			return errors.Wrap(err, "report writing")
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "%d of %d sitemap urls are not linked from the crawled pages\n", len(orphans), len(r.listed))
+
+	return errors.Wrap(err, "report writing")
+}
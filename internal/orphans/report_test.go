@@ -0,0 +1,32 @@
+package orphans
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/crawler"
+	"github.com/vfunin/crawler/internal/parser"
+)
+
+func TestReport(t *testing.T) {
+	r := New()
+
+	r.Add(crawler.Result{URL: "http://go.test/", Links: []parser.Link{{URL: "http://go.test/linked"}}})
+	r.Add(crawler.Result{URL: "http://go.test/linked", Source: "http://go.test/sitemap.xml"})
+	r.Add(crawler.Result{URL: "http://go.test/orphan", Source: "http://go.test/sitemap.xml"})
+	r.Add(crawler.Result{URL: "http://go.test/news/1", Source: "http://go.test/feed.xml"})
+
+	assert.Equal(t, []Orphan{
+		{URL: "http://go.test/news/1", Source: "http://go.test/feed.xml"},
+		{URL: "http://go.test/orphan", Source: "http://go.test/sitemap.xml"},
+	}, r.Orphans())
+
+	var buf bytes.Buffer
+
+	assert.Nil(t, r.Write(&buf))
+	assert.Equal(t, `http://go.test/news/1 (http://go.test/feed.xml)
+http://go.test/orphan (http://go.test/sitemap.xml)
+2 of 3 sitemap urls are not linked from the crawled pages
+`, buf.String())
+}
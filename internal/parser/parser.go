@@ -1,55 +1,103 @@
 package parser
 
 import (
-	"fmt"
 	"io"
 	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/vfunin/crawler/internal/config"
 
 	"github.com/pkg/errors"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
 )
 
+// cssURL - url() reference of a style sheet
+var cssURL = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^)\s]*))\s*\)`) //nolint:gochecknoglobals
+
 type Page interface {
 	Parse(url string, reader io.Reader) (Page, error)
 	Title() string
 	Links() []string
+	Anchors() []Link
+	Robots() Directives
+	Canonical() string
+}
+
+// Link - resolved link of the document with its anchor text
+type Link struct {
+	URL  string
+	Text string
+	// Kind - kind of the link assigned by the extraction rule, e.g. navigation or asset
+	Kind string
+	// NoFollow - the element of the link has rel="nofollow"
+	NoFollow bool
+}
+
+// Directives - robots directives of a page given by meta robots tags or X-Robots-Tag headers
+type Directives struct {
+	NoIndex  bool
+	NoFollow bool
+}
+
+type Option func(p *page)
+
+// WithRules - extracts links with rules instead of the default ones
+func WithRules(rules []config.LinkRule) Option {
+	return func(p *page) {
+		p.rules = rules
+	}
 }
 
 type page struct {
-	title string
-	links []string
+	title     string
+	links     []Link
+	rules     []config.LinkRule
+	robots    Directives
+	canonical string
 }
 
-func New() Page {
-	return &page{title: "", links: nil}
+// attribute - attribute of an element which has been extracted by a rule
+type attribute struct {
+	node *html.Node
+	name string
 }
 
-// Parse - returns page title with links
+func New(opts ...Option) Page {
+	p := &page{title: "", links: nil, rules: config.DefaultLinkRules(), robots: Directives{}, canonical: ""}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Parse - returns page title with links resolved against uri (the document url after redirects)
+// and the <base> element of the document
 func (p *page) Parse(uri string, reader io.Reader) (Page, error) {
 	var (
-		doc       *goquery.Document
-		err       error
-		parsedURL *url.URL
-		links     []string
+		doc     *goquery.Document
+		err     error
+		baseURL *url.URL
 	)
 
 	if doc, err = goquery.NewDocumentFromReader(reader); err != nil {
 		return nil, errors.Wrap(err, "parser document creation")
 	}
 
-	if parsedURL, err = url.Parse(uri); err != nil {
+	if baseURL, err = url.Parse(uri); err != nil {
 		return nil, errors.Wrap(err, "parser url parsing")
 	}
 
-	baseURL := fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
-
-	if links, err = p.parseLinks(doc, baseURL); err != nil {
-		return nil, errors.Wrap(err, "parser links list")
-	}
+	baseURL = p.parseBase(doc, baseURL)
 
 	p.title = p.parseTitle(doc)
-	p.links = links
+	p.links = p.parseLinks(doc, baseURL)
+	p.robots = p.parseRobots(doc)
+	p.canonical = p.parseCanonical(doc, baseURL)
 
 	return p, nil
 }
@@ -58,44 +106,223 @@ func (p *page) Title() string {
 	return p.title
 }
 
-func (p *page) Links() []string {
+func (p *page) Links() (urls []string) {
+	for _, link := range p.links {
+		urls = append(urls, link.URL)
+	}
+
+	return urls
+}
+
+// Anchors - returns links of the document with their anchor text and kind
+func (p *page) Anchors() []Link {
 	return p.links
 }
 
+// Robots - returns the directives of the meta robots tags of the document
+func (p *page) Robots() Directives {
+	return p.robots
+}
+
+// Canonical - returns the resolved url of the first <link rel="canonical">, empty if the document has none
+func (p *page) Canonical() string {
+	return p.canonical
+}
+
+// ParseDirectives - returns the directives of a meta robots content or an X-Robots-Tag value,
+// e.g. "noindex, nofollow", unknown directives are ignored
+func ParseDirectives(value string) (d Directives) {
+	for _, directive := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "noindex":
+			d.NoIndex = true
+		case "nofollow":
+			d.NoFollow = true
+		case "none":
+			d.NoIndex = true
+			d.NoFollow = true
+		}
+	}
+
+	return d
+}
+
+// Merge - returns the directives set in d or o
+func (d Directives) Merge(o Directives) Directives {
+	return Directives{NoIndex: d.NoIndex || o.NoIndex, NoFollow: d.NoFollow || o.NoFollow}
+}
+
 func (p *page) parseTitle(doc *goquery.Document) string {
 	return doc.Find("title").First().Text()
 }
 
-func (p *page) parseLinks(doc *goquery.Document, baseURL string) (urls []string, err error) {
-	fURL := ""
+// parseBase - returns the document base url: the first <base href> resolved against the document url
+func (p *page) parseBase(doc *goquery.Document, docURL *url.URL) *url.URL {
+	href, ok := doc.Find("base[href]").First().Attr("href")
+	if !ok {
+		return docURL
+	}
 
-	doc.Find("a").Each(func(_ int, s *goquery.Selection) {
-		uri, ok := s.Attr("href")
-		if !ok {
-			return
-		}
-		if fURL, err = p.formatURL(uri, baseURL); err != nil {
-			return
-		}
-		urls = append(urls, fURL)
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return docURL
+	}
+
+	return docURL.ResolveReference(ref)
+}
+
+// parseRobots - returns the directives of all meta robots tags of the document
+func (p *page) parseRobots(doc *goquery.Document) (d Directives) {
+	doc.Find(`meta[name="robots" i][content]`).Each(func(_ int, s *goquery.Selection) {
+		content, _ := s.Attr("content")
+		d = d.Merge(ParseDirectives(content))
 	})
 
-	return
+	return d
 }
 
-func (p *page) formatURL(uri string, baseURL string) (string, error) {
-	parsedURL, err := url.Parse(uri)
+// parseCanonical - returns the href of the first canonical link resolved against baseURL
+func (p *page) parseCanonical(doc *goquery.Document, baseURL *url.URL) string {
+	href, ok := doc.Find(`link[rel~="canonical" i][href]`).First().Attr("href")
+	if !ok {
+		return ""
+	}
+
+	canonical, err := p.formatURL(href, baseURL)
 	if err != nil {
-		return "", err
+		return ""
+	}
+
+	return canonical
+}
+
+// parseLinks - returns links of the document extracted by the rules and resolved against baseURL,
+// an attribute of an element is extracted by the first matching rule only, unparsable links are skipped
+func (p *page) parseLinks(doc *goquery.Document, baseURL *url.URL) (links []Link) {
+	seen := make(map[attribute]struct{})
+
+	for _, rule := range p.rules {
+		rule := rule
+
+		doc.Find(rule.Selector).Each(func(_ int, s *goquery.Selection) {
+			attr := attribute{node: s.Get(0), name: rule.Attr}
+			if _, ok := seen[attr]; ok {
+				return
+			}
+
+			seen[attr] = struct{}{}
+
+			value := s.Text()
+			if rule.Attr != "" {
+				value, _ = s.Attr(rule.Attr)
+			}
+
+			text := ""
+			if rule.Format != config.FormatCSS {
+				text = p.anchorText(s)
+			}
+
+			rel, _ := s.Attr("rel")
+			noFollow := hasToken(rel, "nofollow")
+
+			for _, ref := range references(value, rule.Format) {
+				fURL, err := p.formatURL(ref, baseURL)
+				if err != nil {
+					continue
+				}
+
+				links = append(links, Link{URL: fURL, Text: text, Kind: rule.Kind, NoFollow: noFollow})
+			}
+		})
 	}
 
-	if parsedURL.Host == "" {
-		return baseURL + uri, err
+	return
+}
+
+// references - returns the urls of the value in the format, inline data urls are skipped
+func references(value, format string) (refs []string) {
+	switch format {
+	case config.FormatSrcset:
+		for _, candidate := range strings.Split(value, ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				refs = append(refs, fields[0])
+			}
+		}
+	case config.FormatCSS:
+		for _, m := range cssURL.FindAllStringSubmatch(value, -1) {
+			refs = append(refs, m[1]+m[2]+m[3])
+		}
+	case config.FormatRefresh:
+		refs = refreshURL(value)
+	default:
+		refs = []string{value}
 	}
 
-	if parsedURL.Scheme == "" {
-		uri = "https:" + uri
+	kept := refs[:0]
+
+	for _, ref := range refs {
+		if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(ref)), "data:") {
+			kept = append(kept, ref)
+		}
+	}
+
+	return kept
+}
+
+// refreshURL - returns the url of the refresh meta tag content, e.g. "5; url=/next", a plain delay has none
+func refreshURL(content string) []string {
+	i := strings.IndexAny(content, ";,")
+	if i < 0 {
+		return nil
+	}
+
+	ref := strings.TrimSpace(content[i+1:])
+	if len(ref) >= 4 && strings.EqualFold(ref[:4], "url=") {
+		ref = strings.TrimSpace(ref[4:])
+	}
+
+	ref = strings.Trim(ref, `"'`)
+	if ref == "" {
+		return nil
+	}
+
+	return []string{ref}
+}
+
+// hasToken - reports whether the space separated list contains the token, ignoring case
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anchorText - returns the collapsed text of the link, the title attribute or the alt text of the element or its image
+func (p *page) anchorText(s *goquery.Selection) string {
+	if text := strings.Join(strings.Fields(s.Text()), " "); text != "" {
+		return text
+	}
+
+	for _, name := range []string{"title", "alt"} {
+		if value, ok := s.Attr(name); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	alt, _ := s.Find("img[alt]").First().Attr("alt")
+
+	return strings.TrimSpace(alt)
+}
+
+// formatURL - resolves uri against baseURL according to RFC 3986 section 5
+func (p *page) formatURL(uri string, baseURL *url.URL) (string, error) {
+	ref, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return "", err
 	}
 
-	return uri, nil
+	return baseURL.ResolveReference(ref).String(), nil
 }
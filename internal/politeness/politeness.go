@@ -0,0 +1,161 @@
+package politeness
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/retry"
+
+	"golang.org/x/time/rate"
+)
+
+type Politeness interface {
+	Acquire(ctx context.Context, host string) (release func(), err error)
+	SetCrawlDelay(host string, delay time.Duration)
+}
+
+type politeness struct {
+	mu    sync.Mutex
+	cfg   config.Politeness
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	mu         sync.Mutex
+	rules      config.HostPoliteness
+	limiter    *rate.Limiter
+	slots      chan struct{}
+	crawlDelay time.Duration
+	next       time.Time
+}
+
+func New(cfg config.Politeness) Politeness {
+	return &politeness{
+		mu:    sync.Mutex{},
+		cfg:   cfg,
+		hosts: make(map[string]*hostState),
+	}
+}
+
+// Acquire - blocks until a request to host is allowed by the rate limit, the concurrency cap and the delay,
+// the returned release func must be called when the request is finished
+func (p *politeness) Acquire(ctx context.Context, host string) (release func(), err error) {
+	h := p.host(host)
+
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release = func() {
+		if h.slots != nil {
+			<-h.slots
+		}
+	}
+
+	if h.limiter != nil {
+		if err = h.limiter.Wait(ctx); err != nil {
+			release()
+
+			return nil, err
+		}
+	}
+
+	if err = retry.Sleep(ctx, h.reserve()); err != nil {
+		release()
+
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// SetCrawlDelay - sets the minimal pause between requests to host, e.g. from robots.txt, the longest delay
+// of the ports of the host is kept
+func (p *politeness) SetCrawlDelay(host string, delay time.Duration) {
+	h := p.host(host)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if delay > h.crawlDelay {
+		h.crawlDelay = delay
+	}
+}
+
+// host - returns the state of the host, all ports of a host share it like they share the configured rules
+func (p *politeness) host(host string) *hostState {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+
+	host = strings.ToLower(host)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h, ok := p.hosts[host]; ok {
+		return h
+	}
+
+	h := newHostState(p.cfg.ForHost(host))
+	p.hosts[host] = h
+
+	return h
+}
+
+func newHostState(rules config.HostPoliteness) *hostState {
+	h := &hostState{ //nolint:exhaustivestruct
+		mu:    sync.Mutex{},
+		rules: rules,
+	}
+
+	if rules.Rate > 0 {
+		burst := rules.Burst
+		if burst < 1 {
+			burst = 1
+		}
+
+		h.limiter = rate.NewLimiter(rate.Limit(rules.Rate), burst)
+	}
+
+	if rules.MaxConcurrent > 0 {
+		h.slots = make(chan struct{}, rules.MaxConcurrent)
+	}
+
+	return h
+}
+
+// reserve - books the next request time slot for the host and returns how long to wait for it
+func (h *hostState) reserve() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	gap := h.rules.Delay
+	if h.crawlDelay > gap {
+		gap = h.crawlDelay
+	}
+
+	if h.rules.RandomDelay > 0 {
+		gap += time.Duration(rand.Int63n(int64(h.rules.RandomDelay))) //nolint:gosec
+	}
+
+	now := time.Now()
+	start := h.next
+
+	if start.Before(now) {
+		start = now
+	}
+
+	h.next = start.Add(gap)
+
+	return start.Sub(now)
+}
@@ -0,0 +1,132 @@
+package politeness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+)
+
+func rules(r config.HostPoliteness) config.Politeness {
+	return config.Politeness{HostPoliteness: r, Hosts: nil}
+}
+
+func TestNew(t *testing.T) {
+	p := New(config.DefaultPoliteness())
+	assert.NotNil(t, p)
+}
+
+func TestAcquireUnlimited(t *testing.T) {
+	p := New(rules(config.HostPoliteness{Rate: -1, Burst: 0, MaxConcurrent: -1, Delay: 0, RandomDelay: 0}))
+
+	start := time.Now()
+
+	for i := 0; i < 100; i++ {
+		release, err := p.Acquire(context.Background(), "go.test")
+		assert.Nil(t, err)
+		release()
+	}
+
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestAcquireDelay(t *testing.T) {
+	p := New(rules(config.HostPoliteness{Rate: -1, Burst: 0, MaxConcurrent: -1, Delay: 50 * time.Millisecond, RandomDelay: 0}))
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		release, err := p.Acquire(context.Background(), "go.test")
+		assert.Nil(t, err)
+		release()
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	release, err := p.Acquire(context.Background(), "other.test")
+	assert.Nil(t, err)
+	release()
+}
+
+func TestAcquireRate(t *testing.T) {
+	p := New(rules(config.HostPoliteness{Rate: 20, Burst: 1, MaxConcurrent: -1, Delay: 0, RandomDelay: 0}))
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		release, err := p.Acquire(context.Background(), "go.test")
+		assert.Nil(t, err)
+		release()
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestAcquireMaxConcurrent(t *testing.T) {
+	p := New(rules(config.HostPoliteness{Rate: -1, Burst: 0, MaxConcurrent: 1, Delay: 0, RandomDelay: 0}))
+
+	release, err := p.Acquire(context.Background(), "go.test")
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = p.Acquire(ctx, "go.test")
+	assert.NotNil(t, err)
+
+	release()
+
+	release, err = p.Acquire(context.Background(), "go.test")
+	assert.Nil(t, err)
+	release()
+}
+
+func TestSetCrawlDelay(t *testing.T) {
+	p := New(rules(config.HostPoliteness{Rate: -1, Burst: 0, MaxConcurrent: -1, Delay: 0, RandomDelay: 0}))
+	p.SetCrawlDelay("go.test", 50*time.Millisecond)
+
+	start := time.Now()
+
+	for i := 0; i < 2; i++ {
+		release, err := p.Acquire(context.Background(), "go.test")
+		assert.Nil(t, err)
+		release()
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestAcquirePorts(t *testing.T) {
+	p := New(config.Politeness{
+		HostPoliteness: config.HostPoliteness{Rate: -1, Burst: 0, MaxConcurrent: -1, Delay: 0, RandomDelay: 0},
+		Hosts: map[string]config.HostPoliteness{
+			"go.test": {Rate: -1, Burst: 0, MaxConcurrent: 1, Delay: 0, RandomDelay: 0},
+		},
+	})
+
+	release, err := p.Acquire(context.Background(), "go.test:8080")
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = p.Acquire(ctx, "GO.test:443")
+	assert.NotNil(t, err, "the ports of a host share its limits")
+
+	release()
+
+	p.SetCrawlDelay("go.test:8080", 50*time.Millisecond)
+	p.SetCrawlDelay("go.test:443", 0)
+
+	start := time.Now()
+
+	for i := 0; i < 2; i++ {
+		release, err = p.Acquire(context.Background(), "go.test")
+		assert.Nil(t, err)
+		release()
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
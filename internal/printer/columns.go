@@ -0,0 +1,102 @@
+package printer
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/crawler"
+)
+
+const (
+	// redirectsSeparator - separates the urls of a redirect chain inside a single column
+	redirectsSeparator = " "
+	certExpiresLayout  = "2006-01-02"
+)
+
+// values - returns the values of the result columns in the order of columns
+func values(r crawler.Result, columns []string) []string {
+	row := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		row = append(row, value(r, column))
+	}
+
+	return row
+}
+
+func value(r crawler.Result, column string) string {
+	switch column {
+	case config.ColumnURL:
+		return r.URL
+	case config.ColumnTitle:
+		return r.Title
+	case config.ColumnSkipped:
+		return r.Skipped
+	case config.ColumnStatus:
+		if r.StatusCode == 0 {
+			return ""
+		}
+
+		return strconv.Itoa(r.StatusCode)
+	case config.ColumnError:
+		return r.Error
+	case config.ColumnFinalURL:
+		return r.FinalURL
+	case config.ColumnRedirects:
+		return strings.Join(r.Redirects, redirectsSeparator)
+	case config.ColumnContentType:
+		return r.ContentType
+	case config.ColumnContentLength:
+		if r.StatusCode == 0 {
+			return ""
+		}
+
+		return strconv.FormatInt(r.ContentLength, 10)
+	case config.ColumnTruncated:
+		if !r.Truncated {
+			return ""
+		}
+
+		return strconv.FormatBool(r.Truncated)
+	case config.ColumnCharset:
+		return r.Charset
+	case config.ColumnCanonical:
+		return r.Canonical
+	case config.ColumnIndexability:
+		return r.Indexability
+	case config.ColumnLastModified:
+		if r.LastModified.IsZero() {
+			return ""
+		}
+
+		return r.LastModified.UTC().Format(time.RFC3339)
+	case config.ColumnResponseTime:
+		if r.StatusCode == 0 {
+			return ""
+		}
+
+		return strconv.FormatInt(r.ResponseTime.Milliseconds(), 10)
+	case config.ColumnRetries:
+		return strconv.Itoa(r.Retries)
+	case config.ColumnDepth:
+		return strconv.FormatUint(r.Depth, 10)
+	case config.ColumnReferrer:
+		return r.Referrer
+	case config.ColumnKind:
+		return r.Kind
+	case config.ColumnSource:
+		return r.Source
+	case config.ColumnCertIssuer:
+		return r.CertIssuer
+	case config.ColumnCertExpires:
+		if r.CertExpires.IsZero() {
+			return ""
+		}
+
+		return r.CertExpires.UTC().Format(certExpiresLayout)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,67 @@
+package printer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/crawler"
+)
+
+func Test_values(t *testing.T) {
+	r := crawler.Result{
+		URL:           "http://localhost/old",
+		Title:         "Test page",
+		Skipped:       "",
+		StatusCode:    200,
+		Error:         "",
+		FinalURL:      "http://localhost/new",
+		Redirects:     []string{"http://localhost/old", "http://localhost/older"},
+		ContentType:   "text/html",
+		ContentLength: 1024,
+		Truncated:     true,
+		Charset:       "windows-1251",
+		Canonical:     "http://localhost/new",
+		Indexability:  crawler.IndexabilityIndexable,
+		LastModified:  time.Date(2021, 10, 21, 7, 28, 0, 0, time.UTC),
+		ResponseTime:  1500 * time.Millisecond,
+		Retries:       1,
+		Depth:         2,
+		Referrer:      "http://localhost/",
+		Kind:          "navigation",
+		Source:        "http://localhost/sitemap.xml",
+		CertIssuer:    "Test CA",
+		CertExpires:   time.Date(2022, 1, 31, 12, 0, 0, 0, time.UTC),
+	}
+
+	assert.Equal(t, []string{
+		"http://localhost/old",
+		"Test page",
+		"",
+		"200",
+		"",
+		"http://localhost/new",
+		"http://localhost/old http://localhost/older",
+		"text/html",
+		"1024",
+		"true",
+		"windows-1251",
+		"http://localhost/new",
+		"indexable",
+		"2021-10-21T07:28:00Z",
+		"1500",
+		"1",
+		"2",
+		"http://localhost/",
+		"navigation",
+		"http://localhost/sitemap.xml",
+		"Test CA",
+		"2022-01-31",
+	}, values(r, config.AvailableColumns()))
+
+	skipped := crawler.Result{URL: "http://localhost/private", Skipped: crawler.SkipDisallowedByRobots, Depth: 1}
+
+	assert.Equal(t, []string{"", "", "1", crawler.SkipDisallowedByRobots},
+		values(skipped, []string{config.ColumnStatus, config.ColumnResponseTime, config.ColumnDepth, config.ColumnSkipped}))
+}
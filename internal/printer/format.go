@@ -0,0 +1,211 @@
+package printer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/vfunin/crawler/internal/config"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// xmlRoot - root element of the xml output, every result is a resultElement element
+	xmlRoot       = "results"
+	resultElement = "result"
+)
+
+// encoder - writes the rows of the results in an output format
+type encoder interface {
+	// Begin - starts the document, the column names are written as a header if header is set
+	Begin(header bool) error
+	Write(row []string) error
+	// Flush - writes the buffered rows to the output
+	Flush() error
+	// End - completes the document and flushes it
+	End() error
+}
+
+// newEncoder - returns the encoder of the format writing the columns to w
+func newEncoder(format string, delimiter rune, columns []string, w io.Writer) encoder {
+	switch format {
+	case config.FormatJSONL, config.FormatJSON:
+		return &jsonEncoder{w: bufio.NewWriter(w), columns: columns, array: format == config.FormatJSON, rows: 0}
+	case config.FormatXML:
+		return &xmlEncoder{w: bufio.NewWriter(w), columns: columns}
+	case config.FormatTSV:
+		delimiter = '\t'
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	return &csvEncoder{w: writer, columns: columns}
+}
+
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func (e *csvEncoder) Begin(header bool) error {
+	if !header {
+		return nil
+	}
+
+	return e.Write(e.columns)
+}
+
+func (e *csvEncoder) Write(row []string) error {
+	return errors.Wrap(e.w.Write(row), "csv writing")
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+
+	return errors.Wrap(e.w.Error(), "csv flushing")
+}
+
+func (e *csvEncoder) End() error {
+	return e.Flush()
+}
+
+// jsonEncoder - writes a json object with the columns as keys per line, or an array of them
+type jsonEncoder struct {
+	w       *bufio.Writer
+	columns []string
+	array   bool
+	rows    int
+}
+
+func (e *jsonEncoder) Begin(bool) error {
+	if !e.array {
+		return nil
+	}
+
+	_, err := e.w.WriteString("[")
+
+	return errors.Wrap(err, "json writing")
+}
+
+func (e *jsonEncoder) Write(row []string) error {
+	// errors of bufio.Writer are sticky, the one of the last write is returned
+	switch {
+	case e.array && e.rows > 0:
+		_, _ = e.w.WriteString(",\n")
+	case e.array:
+		_ = e.w.WriteByte('\n')
+	}
+
+	e.rows++
+
+	_ = e.w.WriteByte('{')
+
+	for i, column := range e.columns {
+		if i > 0 {
+			_ = e.w.WriteByte(',')
+		}
+
+		_, _ = e.w.Write(jsonString(column))
+		_ = e.w.WriteByte(':')
+		_, _ = e.w.Write(jsonValue(column, row[i]))
+	}
+
+	end := "}\n"
+	if e.array {
+		end = "}"
+	}
+
+	_, err := e.w.WriteString(end)
+
+	return errors.Wrap(err, "json writing")
+}
+
+func (e *jsonEncoder) Flush() error {
+	return errors.Wrap(e.w.Flush(), "json flushing")
+}
+
+func (e *jsonEncoder) End() error {
+	if e.array {
+		end := "]\n"
+		if e.rows > 0 {
+			end = "\n]\n"
+		}
+
+		if _, err := e.w.WriteString(end); err != nil {
+			return errors.Wrap(err, "json writing")
+		}
+	}
+
+	return e.Flush()
+}
+
+// jsonValue - returns the value of a numeric column as a json number, null if it is empty, and the one of
+// the truncated column as a boolean, the values of the other columns are json strings
+func jsonValue(column, value string) []byte {
+	switch column {
+	case config.ColumnStatus, config.ColumnContentLength, config.ColumnResponseTime, config.ColumnRetries, config.ColumnDepth:
+		if value == "" {
+			return []byte("null")
+		}
+
+		return []byte(value)
+	case config.ColumnTruncated:
+		return []byte(strconv.FormatBool(value != ""))
+	default:
+		return jsonString(value)
+	}
+}
+
+func jsonString(value string) []byte {
+	// marshaling a string never fails
+	encoded, _ := json.Marshal(value)
+
+	return encoded
+}
+
+// xmlEncoder - writes every result as an element with an element per column
+type xmlEncoder struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func (e *xmlEncoder) Begin(bool) error {
+	_, err := e.w.WriteString(xml.Header + "<" + xmlRoot + ">\n")
+
+	return errors.Wrap(err, "xml writing")
+}
+
+func (e *xmlEncoder) Write(row []string) error {
+	_, _ = e.w.WriteString("  <" + resultElement + ">")
+
+	for i, column := range e.columns {
+		_, _ = e.w.WriteString("<" + column + ">")
+
+		if err := xml.EscapeText(e.w, []byte(row[i])); err != nil {
+			return errors.Wrap(err, "xml writing")
+		}
+
+		_, _ = e.w.WriteString("</" + column + ">")
+	}
+
+	_, err := e.w.WriteString("</" + resultElement + ">\n")
+
+	return errors.Wrap(err, "xml writing")
+}
+
+func (e *xmlEncoder) Flush() error {
+	return errors.Wrap(e.w.Flush(), "xml flushing")
+}
+
+func (e *xmlEncoder) End() error {
+	if _, err := e.w.WriteString("</" + xmlRoot + ">\n"); err != nil {
+		return errors.Wrap(err, "xml writing")
+	}
+
+	return e.Flush()
+}
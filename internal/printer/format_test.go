@@ -0,0 +1,62 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+)
+
+func encode(t *testing.T, format string, delimiter rune, header bool, rows ...[]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	enc := newEncoder(format, delimiter, []string{config.ColumnURL, config.ColumnTitle, config.ColumnStatus, config.ColumnTruncated}, &buf)
+	assert.Nil(t, enc.Begin(header))
+
+	for _, row := range rows {
+		assert.Nil(t, enc.Write(row))
+	}
+
+	assert.Nil(t, enc.End())
+
+	return buf.String()
+}
+
+func Test_newEncoder(t *testing.T) {
+	page := []string{"http://localhost/", "Fish; \"chips\"\nand more", "200", "true"}
+	skipped := []string{"http://localhost/private", "", "", ""}
+
+	assert.Equal(t, "url;title;status;truncated\nhttp://localhost/;\"Fish; \"\"chips\"\"\nand more\";200;true\n",
+		encode(t, config.FormatCSV, ';', true, page))
+	assert.Equal(t, "http://localhost/,\"Fish; \"\"chips\"\"\nand more\",200,true\n", encode(t, config.FormatCSV, ',', false, page))
+	assert.Equal(t, "url\ttitle\tstatus\ttruncated\nhttp://localhost/private\t\t\t\n", encode(t, config.FormatTSV, ';', true, skipped))
+
+	assert.Equal(t, `{"url":"http://localhost/","title":"Fish; \"chips\"\nand more","status":200,"truncated":true}
+{"url":"http://localhost/private","title":"","status":null,"truncated":false}
+`, encode(t, config.FormatJSONL, ';', true, page, skipped))
+
+	var results []map[string]interface{}
+
+	assert.Nil(t, json.Unmarshal([]byte(encode(t, config.FormatJSON, ';', true, page, skipped)), &results))
+	assert.Len(t, results, 2)
+	assert.Equal(t, "Fish; \"chips\"\nand more", results[0]["title"])
+	assert.Equal(t, "[]\n", encode(t, config.FormatJSON, ';', true))
+
+	out := encode(t, config.FormatXML, ';', true, page)
+	assert.Contains(t, out, "<result><url>http://localhost/</url><title>Fish; &#34;chips&#34;&#xA;and more</title>")
+
+	var doc struct {
+		Results []struct {
+			URL   string `xml:"url"`
+			Title string `xml:"title"`
+		} `xml:"result"`
+	}
+
+	assert.Nil(t, xml.Unmarshal([]byte(out), &doc))
+	assert.Equal(t, "Fish; \"chips\"\nand more", doc.Results[0].Title)
+}
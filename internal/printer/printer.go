@@ -2,93 +2,218 @@ package printer
 
 import (
 	"context"
-	"encoding/csv"
-	"fmt"
+	"io"
 	"os"
 
 	"github.com/vfunin/crawler/internal/config"
 	"github.com/vfunin/crawler/internal/crawler"
+	"github.com/vfunin/crawler/internal/linkcheck"
 
 	"github.com/pkg/errors"
 )
 
+const outputFilePerm = 0o666
+
 type Printer interface {
 	Print()
 }
 
+// Summary - collects the results and is output when the crawl is finished
+type Summary interface {
+	Add(r crawler.Result)
+	Write(w io.Writer) error
+}
+
+// Sink - collects the results and saves them besides the output when the crawl is finished
+type Sink interface {
+	Add(r crawler.Result)
+	Close() error
+}
+
+type Option func(p *printer)
+
+// WithReport - feeds every result to the link check report and outputs the report when the crawl is finished
+func WithReport(r linkcheck.Report) Option {
+	return func(p *printer) {
+		p.report = r
+	}
+}
+
+// WithSummary - feeds every result to s and outputs it after the report when the crawl is finished
+func WithSummary(s Summary) Option {
+	return func(p *printer) {
+		p.summaries = append(p.summaries, s)
+	}
+}
+
+// WithReportOutput - writes the report and the summaries to w instead of stderr, so they are not mixed
+// with the results of the console
+func WithReportOutput(w io.Writer) Option {
+	return func(p *printer) {
+		p.reportOut = w
+	}
+}
+
+// WithSink - feeds every result to s and closes it when the crawl is finished
+func WithSink(s Sink) Option {
+	return func(p *printer) {
+		p.sinks = append(p.sinks, s)
+	}
+}
+
 type printer struct {
-	ctx     context.Context
-	cancel  context.CancelFunc
-	cfg     config.Configuration
-	crawler crawler.Crawler
-	errCh   chan<- error
+	cancel    context.CancelFunc
+	cfg       config.Configuration
+	crawler   crawler.Crawler
+	errCh     chan<- error
+	report    linkcheck.Report
+	summaries []Summary
+	sinks     []Sink
+	reportOut io.Writer
 }
 
-func New(ctx context.Context, cancel context.CancelFunc, cfg config.Configuration, crawler crawler.Crawler, errCh chan<- error) Printer {
-	return &printer{ctx: ctx, cancel: cancel, cfg: cfg, errCh: errCh, crawler: crawler}
+func New(cancel context.CancelFunc, cfg config.Configuration, crawler crawler.Crawler, errCh chan<- error, opts ...Option) Printer {
+	p := &printer{cancel: cancel, cfg: cfg, errCh: errCh, crawler: crawler, report: nil, summaries: nil, sinks: nil,
+		reportOut: os.Stderr}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
-// Print - outputs links to the console or saves to a file
+// Print - outputs links to the console or saves to a file in the configured format until the crawler closes
+// the results channel
 func (p *printer) Print() {
 	var (
-		err        error
-		outputFile *os.File
-		writer     *csv.Writer
+		err error
+		enc encoder
 	)
 
-	if p.cfg.OutputToFile() {
-		writer, outputFile = p.prepareOutputFile()
-		if writer != nil {
-			defer outputFile.Close()
-			defer writer.Flush()
+	columns := p.columns()
+	format := p.cfg.Format()
+
+	switch {
+	case p.cfg.OutputToFile():
+		outputFile, header := p.prepareOutputFile()
+		if outputFile == nil {
+			break
 		}
+		defer outputFile.Close()
+
+		enc = newEncoder(format, p.cfg.Delimiter(), columns, outputFile)
+		err = enc.Begin(header)
+	default:
+		enc = newEncoder(format, p.cfg.Delimiter(), columns, os.Stdout)
+		err = enc.Begin(true)
 	}
 
-	for {
-		select {
-		case <-p.ctx.Done():
+	if err != nil {
+		p.errCh <- errors.Wrap(err, "printer writing header")
+		p.cancel()
+
+		return
+	}
+
+	for msg := range p.crawler.ResultCh() {
+		if p.report != nil {
+			p.report.Add(msg)
+		}
+
+		for _, s := range p.summaries {
+			s.Add(msg)
+		}
+
+		for _, s := range p.sinks {
+			s.Add(msg)
+		}
+
+		// the results of the previous run of a resumed crawl are already in the output
+		if enc == nil || msg.Restored {
+			continue
+		}
+
+		if err = p.write(enc, values(msg, columns)); err != nil {
+			p.errCh <- errors.Wrap(err, "printer writing message")
+			p.cancel()
+
 			return
-		case msg := <-p.crawler.ResultCh():
-			if p.cfg.OutputToFile() {
-				err = writer.Write([]string{msg.URL, msg.Title})
-				if err != nil {
-					p.errCh <- errors.Wrap(err, "printer writing message")
-					p.cancel()
-
-					return
-				}
-
-				break
-			}
-
-			fmt.Printf("%s;%s\n", msg.URL, msg.Title)
-		default:
-			if p.crawler.GetCnt() == 0 {
-				p.cancel()
-			}
+		}
+
+		msg.Written()
+	}
+
+	if enc != nil {
+		if err = enc.End(); err != nil {
+			p.errCh <- errors.Wrap(err, "printer completing output")
+		}
+	}
+
+	if p.report != nil {
+		if err = p.report.Write(p.reportOut); err != nil {
+			p.errCh <- errors.Wrap(err, "printer writing report")
+		}
+	}
+
+	for _, s := range p.summaries {
+		if err = s.Write(p.reportOut); err != nil {
+			p.errCh <- errors.Wrap(err, "printer writing summary")
+		}
+	}
+
+	for _, s := range p.sinks {
+		if err = s.Close(); err != nil {
+			p.errCh <- errors.Wrap(err, "printer closing sink")
 		}
 	}
 }
 
-func (p *printer) prepareOutputFile() (writer *csv.Writer, outputFile *os.File) {
-	var err error
+// write - writes the row, rows output to the console or to the file of a crawl with a checkpoint are flushed at once,
+// so the checkpoint does not record rows lost with the buffer
+func (p *printer) write(enc encoder, row []string) error {
+	if p.cfg.OutputToFile() && p.cfg.CheckpointDir() == "" {
+		return enc.Write(row)
+	}
+
+	if err := enc.Write(row); err != nil {
+		return err
+	}
 
-	if outputFile, err = os.Create(p.cfg.Output()); err != nil {
-		p.errCh <- errors.Wrap(err, "printer file creation")
-		p.cancel()
+	return enc.Flush()
+}
 
-		return
+// columns - returns the configured output columns or the default ones
+func (p *printer) columns() []string {
+	if columns := p.cfg.Columns(); len(columns) > 0 {
+		return columns
 	}
 
-	writer = csv.NewWriter(outputFile)
-	writer.Comma = ';'
+	return config.DefaultColumns()
+}
 
-	if err = writer.Write([]string{"url", "title"}); err != nil {
-		p.errCh <- errors.Wrap(err, "printer writing header")
+// prepareOutputFile - opens the output file, the header is written to new and empty files only
+func (p *printer) prepareOutputFile() (outputFile *os.File, header bool) {
+	var (
+		err  error
+		info os.FileInfo
+	)
+
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if p.cfg.Resume() {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+
+	if outputFile, err = os.OpenFile(p.cfg.Output(), flags, outputFilePerm); err != nil {
+		p.errCh <- errors.Wrap(err, "printer file creation")
 		p.cancel()
 
-		return
+		return nil, false
+	}
+
+	if info, err = outputFile.Stat(); err == nil && info.Size() > 0 {
+		return outputFile, false
 	}
 
-	return
+	return outputFile, true
 }
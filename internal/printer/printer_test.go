@@ -2,19 +2,23 @@ package printer
 
 import (
 	"context"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/certs"
 	"github.com/vfunin/crawler/internal/config"
 	"github.com/vfunin/crawler/internal/crawler"
+	"github.com/vfunin/crawler/internal/linkcheck"
+	"github.com/vfunin/crawler/internal/parser"
 	"github.com/vfunin/crawler/mocks"
 )
 
 func TestNew(t *testing.T) {
 	cwv := context.WithValue(context.Background(), config.LoggerCtxKey, log.Logger)
-	ctx, cancel := context.WithCancel(cwv)
+	_, cancel := context.WithCancel(cwv)
 
 	defer cancel()
 
@@ -25,7 +29,10 @@ func TestNew(t *testing.T) {
 	cfg.On("MaxDepth").Return(1)
 	cfg.On("NeedHelp").Return(false)
 	cfg.On("Output").Return("")
+	cfg.On("Columns").Return(nil)
 	cfg.On("OutputToFile").Return(false)
+	cfg.On("Format").Return(config.FormatCSV)
+	cfg.On("Delimiter").Return(config.DefaultDelimiter)
 	cfg.On("ShowHelp").Return(false)
 	cfg.On("Timeout").Return(0)
 	cfg.On("URL").Return("https://start.url")
@@ -35,15 +42,17 @@ func TestNew(t *testing.T) {
 	c := &mocks.Crawler{}
 
 	c.On("ResultCh").Return(resCh)
-	c.On("GetCnt").Return(int64(0))
-	p := New(ctx, cancel, cfg, c, errCh)
+	close(resCh)
+
+	p := New(cancel, cfg, c, errCh)
 	assert.NotNil(t, p)
+	assert.Equal(t, os.Stderr, p.(*printer).reportOut, "the reports are not mixed with the results")
 	p.Print()
 }
 
 func ExamplePrint() {
 	cwv := context.WithValue(context.Background(), config.LoggerCtxKey, log.Logger)
-	ctx, cancel := context.WithCancel(cwv)
+	_, cancel := context.WithCancel(cwv)
 
 	defer cancel()
 
@@ -54,7 +63,10 @@ func ExamplePrint() {
 	cfg.On("MaxDepth").Return(1)
 	cfg.On("NeedHelp").Return(false)
 	cfg.On("Output").Return("")
+	cfg.On("Columns").Return(nil)
 	cfg.On("OutputToFile").Return(false)
+	cfg.On("Format").Return(config.FormatCSV)
+	cfg.On("Delimiter").Return(config.DefaultDelimiter)
 	cfg.On("ShowHelp").Return(false)
 	cfg.On("Timeout").Return(0)
 	cfg.On("URL").Return("https://start.url")
@@ -64,19 +76,153 @@ func ExamplePrint() {
 	c := &mocks.Crawler{}
 
 	c.On("ResultCh").Return(resCh)
-	c.On("GetCnt").Return(int64(0))
 
 	go func() {
 		resCh <- crawler.Result{
 			URL:   "http://localhost",
 			Title: "Test page",
 		}
+		close(resCh)
+	}()
+
+	p := New(cancel, cfg, c, errCh)
+
+	p.Print()
+	//Output:
+	//url;title;skipped
+	//http://localhost;Test page;
+}
+
+func ExampleWithReport() {
+	cwv := context.WithValue(context.Background(), config.LoggerCtxKey, log.Logger)
+	_, cancel := context.WithCancel(cwv)
+
+	defer cancel()
+
+	errCh := make(chan error)
+	resCh := make(chan crawler.Result)
+	cfg := &mocks.Configuration{}
+	cfg.On("Output").Return("")
+	cfg.On("Columns").Return(nil)
+	cfg.On("OutputToFile").Return(false)
+	cfg.On("Format").Return(config.FormatCSV)
+	cfg.On("Delimiter").Return(config.DefaultDelimiter)
+
+	c := &mocks.Crawler{}
+
+	c.On("ResultCh").Return(resCh)
+
+	go func() {
+		resCh <- crawler.Result{
+			URL:        "http://localhost",
+			Title:      "Test page",
+			StatusCode: 200,
+			Links:      []parser.Link{{URL: "http://localhost/missing", Text: "Missing"}},
+		}
+		resCh <- crawler.Result{
+			URL:        "http://localhost/missing",
+			StatusCode: 404,
+			Referrer:   "http://localhost",
+		}
+		close(resCh)
+	}()
+
+	p := New(cancel, cfg, c, errCh, WithReport(linkcheck.New()), WithReportOutput(os.Stdout))
+
+	p.Print()
+	//Output:
+	//url;title;skipped
+	//http://localhost;Test page;
+	//http://localhost/missing;;
+	//http://localhost
+	//	404 http://localhost/missing "Missing"
+	//1 broken links found on 1 pages
+}
+
+func ExampleWithReport_restored() {
+	cwv := context.WithValue(context.Background(), config.LoggerCtxKey, log.Logger)
+	_, cancel := context.WithCancel(cwv)
+
+	defer cancel()
+
+	errCh := make(chan error)
+	resCh := make(chan crawler.Result)
+	cfg := &mocks.Configuration{}
+	cfg.On("Output").Return("")
+	cfg.On("Columns").Return(nil)
+	cfg.On("OutputToFile").Return(false)
+	cfg.On("Format").Return(config.FormatCSV)
+	cfg.On("Delimiter").Return(config.DefaultDelimiter)
+
+	c := &mocks.Crawler{}
+
+	c.On("ResultCh").Return(resCh)
+
+	go func() {
+		// the page has been output by the previous run of the resumed crawl
+		resCh <- crawler.Result{
+			URL:        "http://localhost",
+			Title:      "Test page",
+			StatusCode: 200,
+			Links:      []parser.Link{{URL: "http://localhost/missing", Text: "Missing"}},
+			Restored:   true,
+		}
+		resCh <- crawler.Result{
+			URL:        "http://localhost/missing",
+			StatusCode: 404,
+			Referrer:   "http://localhost",
+		}
+		close(resCh)
+	}()
+
+	p := New(cancel, cfg, c, errCh, WithReport(linkcheck.New()), WithReportOutput(os.Stdout))
+
+	p.Print()
+	//Output:
+	//url;title;skipped
+	//http://localhost/missing;;
+	//http://localhost
+	//	404 http://localhost/missing "Missing"
+	//1 broken links found on 1 pages
+}
+
+func ExampleWithSummary() {
+	cwv := context.WithValue(context.Background(), config.LoggerCtxKey, log.Logger)
+	_, cancel := context.WithCancel(cwv)
+
+	defer cancel()
+
+	errCh := make(chan error)
+	resCh := make(chan crawler.Result)
+	cfg := &mocks.Configuration{}
+	cfg.On("Output").Return("")
+	cfg.On("Columns").Return(nil)
+	cfg.On("OutputToFile").Return(false)
+	cfg.On("Format").Return(config.FormatCSV)
+	cfg.On("Delimiter").Return(config.DefaultDelimiter)
+
+	c := &mocks.Crawler{}
+
+	c.On("ResultCh").Return(resCh)
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	go func() {
+		resCh <- crawler.Result{
+			URL:         "https://localhost",
+			Title:       "Test page",
+			CertIssuer:  "Test CA",
+			CertExpires: now.AddDate(0, 0, 7),
+		}
+		close(resCh)
 	}()
-	time.Sleep(time.Second)
 
-	p := New(ctx, cancel, cfg, c, errCh)
+	p := New(cancel, cfg, c, errCh, WithSummary(certs.New(30, now)), WithReportOutput(os.Stdout))
 
 	p.Print()
 	//Output:
-	//http://localhost;Test page
+	//url;title;skipped
+	//https://localhost;Test page;
+	//localhost expires 2022-01-08 (Test CA)
+	//1 of 1 certificates expire within 30 days
 }
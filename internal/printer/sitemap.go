@@ -0,0 +1,244 @@
+package printer
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/crawler"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// sitemapXMLNS - namespace of the sitemaps protocol
+	sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	// maxSitemapURLs - urls of a single sitemap, the limit of the sitemaps protocol
+	maxSitemapURLs = 50000
+	// maxSitemapSize - size of a single uncompressed sitemap, the limit of the sitemaps protocol
+	maxSitemapSize = 50 << 20
+	gzipExt        = ".gz"
+)
+
+// sitemapURL - url element of an urlset, the sitemap element of an index has the same content
+type sitemapURL struct {
+	XMLName xml.Name
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// sitemap - part of the urls fitting into one sitemap file
+type sitemap struct {
+	entries [][]byte
+	size    int
+	lastMod time.Time
+}
+
+type sitemapSink struct {
+	cfg  config.SitemapOutput
+	urls []sitemapURL
+	mods []time.Time
+	seen map[string]struct{}
+}
+
+// NewSitemap - returns the sink writing the indexable pages answered with 200 to the sitemap of cfg,
+// a sitemap index with numbered sitemaps next to it is written when they do not fit into one sitemap
+func NewSitemap(cfg config.SitemapOutput) Sink {
+	return &sitemapSink{cfg: cfg, urls: nil, mods: nil, seen: make(map[string]struct{})}
+}
+
+// Add - collects the url of an indexable page, the final url is listed for redirected urls,
+// the urls only checked in the link check mode are not listed
+func (s *sitemapSink) Add(r crawler.Result) {
+	if r.Skipped != "" || r.External || r.StatusCode != http.StatusOK ||
+		r.Indexability != crawler.IndexabilityIndexable || r.Kind == config.LinkAsset {
+		return
+	}
+
+	loc := r.URL
+	if len(r.Redirects) > 0 && r.FinalURL != "" {
+		loc = r.FinalURL
+	}
+
+	if _, ok := s.seen[loc]; ok {
+		return
+	}
+
+	s.seen[loc] = struct{}{}
+
+	u := sitemapURL{XMLName: xml.Name{Space: "", Local: "url"}, Loc: loc, LastMod: ""}
+	if !r.LastModified.IsZero() {
+		u.LastMod = r.LastModified.UTC().Format(time.RFC3339)
+	}
+
+	s.urls = append(s.urls, u)
+	s.mods = append(s.mods, r.LastModified)
+}
+
+// Close - writes the collected urls
+func (s *sitemapSink) Close() error {
+	parts, err := s.split()
+	if err != nil {
+		return err
+	}
+
+	path := s.name(s.cfg.Path)
+
+	if len(parts) == 1 {
+		return writeSitemap(path, "urlset", parts[0].entries)
+	}
+
+	base, err := s.base()
+	if err != nil {
+		return err
+	}
+
+	index := make([][]byte, 0, len(parts))
+
+	for i, part := range parts {
+		name := s.part(i + 1)
+		if err = writeSitemap(name, "urlset", part.entries); err != nil {
+			return err
+		}
+
+		ref := sitemapURL{XMLName: xml.Name{Space: "", Local: "sitemap"}, Loc: "", LastMod: ""}
+		ref.Loc = base.ResolveReference(&url.URL{Path: filepath.Base(name)}).String() //nolint:exhaustivestruct
+
+		if !part.lastMod.IsZero() {
+			ref.LastMod = part.lastMod.UTC().Format(time.RFC3339)
+		}
+
+		entry, mErr := xml.Marshal(ref)
+		if mErr != nil {
+			return errors.Wrap(mErr, "sitemap index entry")
+		}
+
+		index = append(index, entry)
+	}
+
+	return writeSitemap(path, "sitemapindex", index)
+}
+
+// split - marshals the urls and splits them into sitemaps within the limits of the protocol
+func (s *sitemapSink) split() ([]sitemap, error) {
+	parts := []sitemap{{entries: nil, size: 0, lastMod: time.Time{}}}
+	limit := maxSitemapSize - len(header("urlset")) - len(footer("urlset"))
+
+	for i, u := range s.urls {
+		entry, err := xml.Marshal(u)
+		if err != nil {
+			return nil, errors.Wrap(err, "sitemap entry")
+		}
+
+		last := &parts[len(parts)-1]
+		if len(last.entries) == maxSitemapURLs || last.size+len(entry)+1 > limit {
+			parts = append(parts, sitemap{entries: nil, size: 0, lastMod: time.Time{}})
+			last = &parts[len(parts)-1]
+		}
+
+		last.entries = append(last.entries, entry)
+		last.size += len(entry) + 1
+
+		if s.mods[i].After(last.lastMod) {
+			last.lastMod = s.mods[i]
+		}
+	}
+
+	return parts, nil
+}
+
+// base - returns the url the sitemaps are published at, the root of the first url unless it is configured
+func (s *sitemapSink) base() (*url.URL, error) {
+	raw := s.cfg.BaseURL
+	if raw == "" {
+		first, err := url.Parse(s.urls[0].Loc)
+		if err != nil {
+			return nil, errors.Wrap(err, "sitemap base url")
+		}
+
+		raw = first.Scheme + "://" + first.Host + "/"
+	}
+
+	if !strings.HasSuffix(raw, "/") {
+		raw += "/"
+	}
+
+	base, err := url.Parse(raw)
+
+	return base, errors.Wrap(err, "sitemap base url")
+}
+
+// name - appends .gz to the file name of compressed sitemaps unless it is there
+func (s *sitemapSink) name(path string) string {
+	if s.cfg.Gzip && !strings.HasSuffix(path, gzipExt) {
+		return path + gzipExt
+	}
+
+	return path
+}
+
+// part - returns the file name of the numbered sitemap of an index, sitemap.xml -> sitemap-1.xml
+func (s *sitemapSink) part(n int) string {
+	path := strings.TrimSuffix(s.cfg.Path, gzipExt)
+	ext := filepath.Ext(path)
+
+	return s.name(strings.TrimSuffix(path, ext) + "-" + strconv.Itoa(n) + ext)
+}
+
+// writeSitemap - writes the entries wrapped into the root element, files with the .gz extension are compressed
+func writeSitemap(path, root string, entries [][]byte) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "sitemap file creation")
+	}
+
+	defer func() {
+		if cErr := file.Close(); cErr != nil && err == nil {
+			err = errors.Wrap(cErr, "sitemap file closing")
+		}
+	}()
+
+	var w io.Writer = file
+
+	if strings.HasSuffix(path, gzipExt) {
+		gz := gzip.NewWriter(file)
+
+		defer func() {
+			if cErr := gz.Close(); cErr != nil && err == nil {
+				err = errors.Wrap(cErr, "sitemap compression")
+			}
+		}()
+
+		w = gz
+	}
+
+	if _, err = io.WriteString(w, header(root)); err != nil {
+		return errors.Wrap(err, "sitemap writing")
+	}
+
+	for _, entry := range entries {
+		if _, err = w.Write(append(entry, '\n')); err != nil {
+			return errors.Wrap(err, "sitemap writing")
+		}
+	}
+
+	_, err = io.WriteString(w, footer(root))
+
+	return errors.Wrap(err, "sitemap writing")
+}
+
+func header(root string) string {
+	return xml.Header + "<" + root + ` xmlns="` + sitemapXMLNS + `">` + "\n"
+}
+
+func footer(root string) string {
+	return "</" + root + ">\n"
+}
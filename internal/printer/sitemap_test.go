@@ -0,0 +1,90 @@
+package printer
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/crawler"
+)
+
+func page(u string) crawler.Result {
+	return crawler.Result{URL: u, StatusCode: 200, Indexability: crawler.IndexabilityIndexable} //nolint:exhaustivestruct
+}
+
+func TestSitemap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sitemap.xml")
+	s := NewSitemap(config.SitemapOutput{Path: path, Gzip: false, BaseURL: ""})
+
+	updated := page("http://localhost/a&b")
+	updated.LastModified = time.Date(2021, 10, 21, 7, 28, 0, 0, time.FixedZone("MSK", 3*60*60))
+	redirected := page("http://localhost/old")
+	redirected.Redirects = []string{"http://localhost/old"}
+	redirected.FinalURL = "http://localhost/"
+	noindex := page("http://localhost/private")
+	noindex.Indexability = crawler.IndexabilityNoIndex
+	missing := page("http://localhost/missing")
+	missing.StatusCode = 404
+	asset := page("http://localhost/logo.png")
+	asset.Kind = config.LinkAsset
+	external := page("http://other.localhost/")
+	external.External = true
+
+	for _, r := range []crawler.Result{page("http://localhost/"), updated, redirected, noindex, missing, asset, external} {
+		s.Add(r)
+	}
+
+	assert.Nil(t, s.Close())
+
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://localhost/</loc></url>
+<url><loc>http://localhost/a&amp;b</loc><lastmod>2021-10-21T04:28:00Z</lastmod></url>
+</urlset>
+`, string(content))
+}
+
+func TestSitemapIndex(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSitemap(config.SitemapOutput{Path: filepath.Join(dir, "sitemap.xml"), Gzip: true, BaseURL: "https://cdn.localhost/maps"})
+
+	for i := 0; i <= maxSitemapURLs; i++ {
+		s.Add(page("http://localhost/" + strconv.Itoa(i)))
+	}
+
+	assert.Nil(t, s.Close())
+
+	index := readGzip(t, filepath.Join(dir, "sitemap.xml.gz"))
+	assert.Contains(t, index, "<sitemapindex")
+	assert.Contains(t, index, "<sitemap><loc>https://cdn.localhost/maps/sitemap-1.xml.gz</loc></sitemap>")
+	assert.Contains(t, index, "<sitemap><loc>https://cdn.localhost/maps/sitemap-2.xml.gz</loc></sitemap>")
+
+	assert.Equal(t, maxSitemapURLs, strings.Count(readGzip(t, filepath.Join(dir, "sitemap-1.xml.gz")), "<url>"))
+	assert.Equal(t, 1, strings.Count(readGzip(t, filepath.Join(dir, "sitemap-2.xml.gz")), "<url>"))
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	assert.Nil(t, err)
+
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	assert.Nil(t, err)
+
+	content, err := io.ReadAll(gz)
+	assert.Nil(t, err)
+
+	return string(content)
+}
@@ -0,0 +1,102 @@
+package retry
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vfunin/crawler/internal/config"
+)
+
+type Breaker interface {
+	// Wait - blocks while the host is paused
+	Wait(ctx context.Context, host string) error
+	// Success - closes the breaker of the host
+	Success(host string)
+	// Failure - counts a transient failure of the host and returns the time until which the host is paused,
+	// zero time if the host is not paused
+	Failure(host string) time.Time
+}
+
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	hosts     map[string]*hostState
+}
+
+type hostState struct {
+	failures  int
+	openUntil time.Time
+}
+
+// NewBreaker - returns a per-host circuit breaker, after the cooldown a single failure pauses the host again
+func NewBreaker(cfg config.Retry) Breaker {
+	return &breaker{
+		mu:        sync.Mutex{},
+		threshold: cfg.BreakerThreshold,
+		cooldown:  cfg.BreakerCooldown,
+		hosts:     make(map[string]*hostState),
+	}
+}
+
+func (b *breaker) Wait(ctx context.Context, host string) error {
+	for {
+		b.mu.Lock()
+		wait := time.Until(b.host(host).openUntil)
+		b.mu.Unlock()
+
+		if wait <= 0 {
+			return nil
+		}
+
+		if err := Sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *breaker) Success(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := b.host(host)
+	h.failures = 0
+	h.openUntil = time.Time{}
+}
+
+func (b *breaker) Failure(host string) time.Time {
+	if b.threshold <= 0 {
+		return time.Time{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := b.host(host)
+	h.failures++
+
+	if h.failures < b.threshold {
+		return time.Time{}
+	}
+
+	// the host stays half-open after the cooldown: the next failure pauses it again
+	h.failures = b.threshold - 1
+	h.openUntil = time.Now().Add(b.cooldown)
+
+	return h.openUntil
+}
+
+// host - returns the state of the host, must be called with mu held
+func (b *breaker) host(host string) *hostState {
+	host = strings.ToLower(host)
+
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &hostState{failures: 0, openUntil: time.Time{}}
+		b.hosts[host] = h
+	}
+
+	return h
+}
@@ -0,0 +1,43 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+)
+
+func TestBreaker(t *testing.T) {
+	b := NewBreaker(config.Retry{BreakerThreshold: 2, BreakerCooldown: 50 * time.Millisecond}) //nolint:exhaustivestruct
+	ctx := context.Background()
+
+	assert.True(t, b.Failure("go.test").IsZero())
+	b.Success("go.test")
+	assert.True(t, b.Failure("go.test").IsZero())
+	assert.False(t, b.Failure("Go.Test").IsZero())
+
+	start := time.Now()
+
+	assert.Nil(t, b.Wait(ctx, "other.test"))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+	assert.Nil(t, b.Wait(ctx, "go.test"))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	// half-open after the cooldown: a single failure pauses the host again
+	assert.False(t, b.Failure("go.test").IsZero())
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	assert.ErrorIs(t, b.Wait(cancelled, "go.test"), context.Canceled)
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := NewBreaker(config.Retry{BreakerThreshold: -1}) //nolint:exhaustivestruct
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, b.Failure("go.test").IsZero())
+	}
+}
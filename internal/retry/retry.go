@@ -0,0 +1,101 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/fetcher"
+)
+
+type Policy interface {
+	// MaxRetries - returns the number of retries after the first request
+	MaxRetries() int
+	// Backoff - returns the pause before the retry with number attempt counted from zero
+	Backoff(attempt int, retryAfter time.Duration) time.Duration
+}
+
+type policy struct {
+	cfg config.Retry
+}
+
+func New(cfg config.Retry) Policy {
+	return &policy{cfg: cfg}
+}
+
+func (p *policy) MaxRetries() int {
+	if p.cfg.MaxRetries < 0 {
+		return 0
+	}
+
+	return p.cfg.MaxRetries
+}
+
+// Backoff - returns a random pause up to the exponentially growing bound (full jitter),
+// the Retry-After value is used instead when it is given, both are limited by MaxBackoff
+func (p *policy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return p.limit(retryAfter)
+	}
+
+	bound := p.cfg.InitialBackoff
+	for i := 0; i < attempt && bound < p.cfg.MaxBackoff; i++ {
+		bound *= 2
+	}
+
+	bound = p.limit(bound)
+	if bound <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(bound)) + 1) //nolint:gosec
+}
+
+func (p *policy) limit(d time.Duration) time.Duration {
+	if p.cfg.MaxBackoff > 0 && d > p.cfg.MaxBackoff {
+		return p.cfg.MaxBackoff
+	}
+
+	return d
+}
+
+// Transient - reports whether a repeated request may succeed: timeouts, connection failures, 429 and 5xx
+// except 501, dns and tls failures as well as cancellation are permanent
+func Transient(resp *fetcher.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+
+		kind := fetcher.Describe(err)
+
+		return kind == fetcher.FailureTimeout || kind == fetcher.FailureConnection
+	}
+
+	if resp == nil {
+		return false
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented
+}
+
+// Sleep - waits for d, returns the error of ctx if it is done before
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
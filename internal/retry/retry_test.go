@@ -0,0 +1,69 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/fetcher"
+)
+
+func TestPolicy_MaxRetries(t *testing.T) {
+	assert.Equal(t, config.DefaultMaxRetries, New(config.DefaultRetry()).MaxRetries())
+	assert.Equal(t, 0, New(config.Retry{MaxRetries: -1}).MaxRetries()) //nolint:exhaustivestruct
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	p := New(config.Retry{ //nolint:exhaustivestruct
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	})
+
+	for i := 0; i < 100; i++ {
+		assert.LessOrEqual(t, p.Backoff(0, 0), 100*time.Millisecond)
+		assert.Positive(t, p.Backoff(0, 0))
+		assert.LessOrEqual(t, p.Backoff(2, 0), 400*time.Millisecond)
+		assert.LessOrEqual(t, p.Backoff(10, 0), time.Second)
+	}
+
+	assert.Equal(t, 500*time.Millisecond, p.Backoff(0, 500*time.Millisecond))
+	assert.Equal(t, time.Second, p.Backoff(0, time.Hour))
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *fetcher.Response
+		err  error
+		want bool
+	}{
+		{name: "ok", resp: &fetcher.Response{StatusCode: http.StatusOK}, err: nil, want: false},                          //nolint:exhaustivestruct
+		{name: "not found", resp: &fetcher.Response{StatusCode: http.StatusNotFound}, err: nil, want: false},             //nolint:exhaustivestruct
+		{name: "too many", resp: &fetcher.Response{StatusCode: http.StatusTooManyRequests}, err: nil, want: true},        //nolint:exhaustivestruct
+		{name: "unavailable", resp: &fetcher.Response{StatusCode: http.StatusServiceUnavailable}, err: nil, want: true},  //nolint:exhaustivestruct
+		{name: "not implemented", resp: &fetcher.Response{StatusCode: http.StatusNotImplemented}, err: nil, want: false}, //nolint:exhaustivestruct
+		{name: "timeout", resp: nil, err: context.DeadlineExceeded, want: true},
+		{name: "canceled", resp: nil, err: context.Canceled, want: false},
+		{name: "unknown", resp: nil, err: errors.New("unknown"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Transient(tt.resp, tt.err))
+		})
+	}
+}
+
+func TestSleep(t *testing.T) {
+	assert.Nil(t, Sleep(context.Background(), 0))
+	assert.Nil(t, Sleep(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, Sleep(ctx, time.Hour), context.Canceled)
+}
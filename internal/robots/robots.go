@@ -0,0 +1,177 @@
+package robots
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	Path = "/robots.txt"
+	// maxSize - robots.txt content after this limit is ignored
+	maxSize = 500 * 1024
+)
+
+type Robots interface {
+	Allowed(ctx context.Context, uri string) (bool, error)
+	Sitemaps(ctx context.Context, uri string) ([]string, error)
+}
+
+// CrawlDelayFunc - receives the Crawl-delay of a host once its robots.txt is loaded
+type CrawlDelayFunc func(host string, delay time.Duration)
+
+type entry struct {
+	ready chan struct{}
+	rules *Rules
+}
+
+type robots struct {
+	mu           sync.Mutex
+	userAgent    string
+	client       *http.Client
+	onCrawlDelay CrawlDelayFunc
+	hosts        map[string]*entry
+}
+
+type Option func(r *robots)
+
+// WithRoundTripper - fetches robots.txt with rt, e.g. the transport shared with the fetcher
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return func(r *robots) {
+		r.client.Transport = rt
+	}
+}
+
+func New(userAgent string, timeout time.Duration, onCrawlDelay CrawlDelayFunc, opts ...Option) Robots {
+	r := &robots{
+		mu:           sync.Mutex{},
+		userAgent:    userAgent,
+		client:       &http.Client{Timeout: timeout}, //nolint:exhaustivestruct
+		onCrawlDelay: onCrawlDelay,
+		hosts:        make(map[string]*entry),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Allowed - reports whether uri may be crawled, robots.txt of the host is fetched once and cached
+func (r *robots) Allowed(ctx context.Context, uri string) (bool, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false, errors.Wrap(err, "robots url parsing")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return true, nil
+	}
+
+	rules, err := r.rules(ctx, u)
+	if err != nil {
+		return false, err
+	}
+
+	return rules.Allowed(u.RequestURI()), nil
+}
+
+// Sitemaps - returns the sitemap urls listed in robots.txt of the uri host resolved against it
+func (r *robots) Sitemaps(ctx context.Context, uri string) (sitemaps []string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.Wrap(err, "robots url parsing")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, nil
+	}
+
+	rules, err := r.rules(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	base := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: Path} //nolint:exhaustivestruct
+
+	for _, sitemap := range rules.Sitemaps() {
+		ref, pErr := url.Parse(sitemap)
+		if pErr != nil {
+			continue
+		}
+
+		sitemaps = append(sitemaps, base.ResolveReference(ref).String())
+	}
+
+	return sitemaps, nil
+}
+
+func (r *robots) rules(ctx context.Context, u *url.URL) (*Rules, error) {
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	r.mu.Lock()
+	e, ok := r.hosts[key]
+
+	if !ok {
+		e = &entry{ready: make(chan struct{}), rules: nil}
+		r.hosts[key] = e
+		r.mu.Unlock()
+
+		e.rules = r.load(ctx, key)
+		close(e.ready)
+
+		if r.onCrawlDelay != nil && e.rules.CrawlDelay() > 0 {
+			r.onCrawlDelay(u.Host, e.rules.CrawlDelay())
+		}
+
+		return e.rules, nil
+	}
+
+	r.mu.Unlock()
+
+	select {
+	case <-e.ready:
+		return e.rules, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load - fetches robots.txt: a missing file allows everything, an unreachable one disallows everything
+func (r *robots) load(ctx context.Context, base string) *Rules {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+Path, nil)
+	if err != nil {
+		return DisallowAll()
+	}
+
+	if r.userAgent != "" {
+		req.Header.Set("User-Agent", r.userAgent)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return DisallowAll()
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		rules, pErr := Parse(io.LimitReader(resp.Body, maxSize), r.userAgent)
+		if pErr != nil {
+			return AllowAll()
+		}
+
+		return rules
+	case resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError:
+		return AllowAll()
+	default:
+		return DisallowAll()
+	}
+}
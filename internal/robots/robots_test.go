@@ -0,0 +1,87 @@
+package robots
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	r := New("crawler", time.Second, nil)
+	assert.NotNil(t, r)
+}
+
+func TestAllowed(t *testing.T) {
+	var (
+		requests int32
+		delay    time.Duration
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, Path, r.URL.Path)
+		assert.Equal(t, "crawler/1.0", r.UserAgent())
+
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\nCrawl-delay: 2\n"))
+	}))
+	defer server.Close()
+
+	r := New("crawler/1.0", time.Second, func(host string, d time.Duration) {
+		u, _ := url.Parse(server.URL)
+		assert.Equal(t, u.Host, host)
+		delay = d
+	})
+
+	allowed, err := r.Allowed(context.Background(), server.URL+"/public")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = r.Allowed(context.Background(), server.URL+"/private/page")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Equal(t, 2*time.Second, delay)
+}
+
+func TestSitemaps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("User-agent: *\nDisallow:\nSitemap: /sitemap.xml\nSitemap: https://cdn.go.test/news.xml\n"))
+	}))
+	defer server.Close()
+
+	sitemaps, err := New("crawler/1.0", time.Second, nil).Sitemaps(context.Background(), server.URL+"/page")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{server.URL + "/sitemap.xml", "https://cdn.go.test/news.xml"}, sitemaps)
+}
+
+func TestAllowedStatuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   bool
+	}{
+		{name: "not found allows all", status: http.StatusNotFound, want: true},
+		{name: "forbidden allows all", status: http.StatusForbidden, want: true},
+		{name: "server error disallows all", status: http.StatusServiceUnavailable, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			allowed, err := New("crawler", time.Second, nil).Allowed(context.Background(), server.URL+"/page")
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, allowed)
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package robots
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wildcardAgent = "*"
+	maxLineLength = 16 * 1024
+)
+
+type rule struct {
+	pattern string
+	allow   bool
+}
+
+// Rules - Allow/Disallow rules and Crawl-delay of the robots.txt group matching our user agent
+// with the sitemaps listed in the file
+type Rules struct {
+	rules       []rule
+	crawlDelay  time.Duration
+	disallowAll bool
+	sitemaps    []string
+}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// AllowAll - rules used when robots.txt is missing
+func AllowAll() *Rules {
+	return &Rules{rules: nil, crawlDelay: 0, disallowAll: false, sitemaps: nil}
+}
+
+// DisallowAll - rules used when robots.txt is unreachable
+func DisallowAll() *Rules {
+	return &Rules{rules: nil, crawlDelay: 0, disallowAll: true, sitemaps: nil}
+}
+
+// Parse - reads robots.txt and returns the rules of the group matching userAgent
+func Parse(reader io.Reader, userAgent string) (*Rules, error) {
+	groups, sitemaps, err := parseGroups(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := selectRules(groups, userAgent)
+	rules.sitemaps = sitemaps
+
+	return rules, nil
+}
+
+// parseGroups - returns the user agent groups and the Sitemap lines, which do not belong to any group
+func parseGroups(reader io.Reader) (groups []*group, sitemaps []string, err error) {
+	var (
+		current   *group
+		lastAgent bool
+	)
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, maxLineLength), maxLineLength)
+
+	for scanner.Scan() {
+		key, value, ok := splitLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastAgent {
+				current = &group{agents: nil, rules: nil, crawlDelay: 0}
+				groups = append(groups, current)
+			}
+
+			current.agents = append(current.agents, productToken(value))
+			lastAgent = true
+
+			continue
+		case "allow", "disallow":
+			if current != nil && value != "" {
+				current.rules = append(current.rules, rule{pattern: value, allow: key == "allow"})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if seconds, pErr := strconv.ParseFloat(value, 64); pErr == nil && seconds > 0 {
+					current.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		case "sitemap":
+			if value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+		}
+
+		lastAgent = false
+	}
+
+	return groups, sitemaps, scanner.Err()
+}
+
+func splitLine(line string) (key, value string, ok bool) {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return "", "", false
+	}
+
+	key = strings.ToLower(strings.TrimSpace(line[:i]))
+	value = strings.TrimSpace(line[i+1:])
+
+	return key, value, key != ""
+}
+
+// productToken - returns the lowercased product token the user agent starts with, e.g. crawler for
+// "Crawler/1.0 (+https://go.test)", "*" is kept as it is
+func productToken(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == wildcardAgent {
+		return wildcardAgent
+	}
+
+	end := strings.IndexFunc(userAgent, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-' || r == '_')
+	})
+	if end < 0 {
+		end = len(userAgent)
+	}
+
+	return strings.ToLower(userAgent[:end])
+}
+
+// selectRules - merges the groups whose user agent equals our product token (RFC 9309), falls back to the "*" groups
+func selectRules(groups []*group, userAgent string) *Rules {
+	result := AllowAll()
+
+	if token := productToken(userAgent); token != "" && token != wildcardAgent && mergeGroups(groups, token, result) {
+		return result
+	}
+
+	mergeGroups(groups, wildcardAgent, result)
+
+	return result
+}
+
+// mergeGroups - adds the rules of the groups of agent to result, reports whether there are any
+func mergeGroups(groups []*group, agent string, result *Rules) (found bool) {
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a != agent {
+				continue
+			}
+
+			found = true
+			result.rules = append(result.rules, g.rules...)
+
+			if g.crawlDelay > result.crawlDelay {
+				result.crawlDelay = g.crawlDelay
+			}
+
+			break
+		}
+	}
+
+	return found
+}
+
+// CrawlDelay - returns the pause between requests requested by the site
+func (r *Rules) CrawlDelay() time.Duration {
+	return r.crawlDelay
+}
+
+// Sitemaps - returns the urls of the Sitemap lines as written in robots.txt
+func (r *Rules) Sitemaps() []string {
+	return r.sitemaps
+}
+
+// Allowed - reports whether path (with query) may be fetched, the longest matching rule wins
+// and Allow wins over Disallow of the same length
+func (r *Rules) Allowed(path string) bool {
+	if r.disallowAll {
+		return false
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	allowed, matched := true, -1
+
+	for _, rl := range r.rules {
+		if !match(rl.pattern, path) {
+			continue
+		}
+
+		if l := len(rl.pattern); l > matched || l == matched && rl.allow {
+			allowed, matched = rl.allow, l
+		}
+	}
+
+	return allowed
+}
+
+// match - matches path against a robots.txt pattern supporting "*" wildcards and the "$" end anchor
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	rest := path[len(parts[0]):]
+
+	for i := 1; i < len(parts); i++ {
+		part := parts[i]
+
+		if i == len(parts)-1 && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+
+		rest = rest[idx+len(part):]
+	}
+
+	return !anchored || rest == ""
+}
@@ -0,0 +1,117 @@
+package robots
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const robotsTxt = `
+# comment
+User-agent: *
+Disallow: /private/
+Disallow: /*.pdf$
+Allow: /private/public/
+
+User-agent: crawler
+User-agent: other
+Disallow: /admin
+Allow: /admin/help$
+Crawl-delay: 1.5
+
+User-agent: crawler-images
+Disallow: /
+
+User-agent: bot
+User-agent: Crawl
+Disallow: /
+
+User-agent: NewsBot/2.1
+Disallow: /news/
+
+Sitemap: https://go.test/sitemap.xml
+sitemap: /news.xml # relative
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{name: "wildcard group allows root", userAgent: "somebot/1.0", path: "/", want: true},
+		{name: "wildcard group disallows prefix", userAgent: "somebot/1.0", path: "/private/page.html", want: false},
+		{name: "longest allow wins", userAgent: "somebot/1.0", path: "/private/public/page.html", want: true},
+		{name: "end anchor matches", userAgent: "somebot/1.0", path: "/docs/file.pdf", want: false},
+		{name: "end anchor does not match query", userAgent: "somebot/1.0", path: "/docs/file.pdf?x=1", want: true},
+		{name: "specific group replaces wildcard", userAgent: "crawler/1.0 (+https://go.test)", path: "/private/page.html", want: true},
+		{name: "specific group disallows", userAgent: "crawler/1.0", path: "/admin/users", want: false},
+		{name: "specific group anchored allow", userAgent: "crawler/1.0", path: "/admin/help", want: true},
+		{name: "specific group anchored allow mismatch", userAgent: "crawler/1.0", path: "/admin/help/more", want: false},
+		{name: "most specific agent wins", userAgent: "crawler-images/1.0", path: "/index.html", want: false},
+		{name: "user agent is case insensitive", userAgent: "Crawler/1.0", path: "/admin", want: false},
+		{name: "agent contained in the token does not match", userAgent: "somebot/1.0", path: "/page", want: true},
+		{name: "agent prefix of the token does not match", userAgent: "crawler/1.0", path: "/page", want: true},
+		{name: "version of the agent line is ignored", userAgent: "newsbot/3.0", path: "/news/today", want: false},
+		{name: "unmatched agent falls back to wildcard", userAgent: "newsbot-images", path: "/private/a", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(strings.NewReader(robotsTxt), tt.userAgent)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, r.Allowed(tt.path))
+		})
+	}
+}
+
+func TestParseCrawlDelay(t *testing.T) {
+	r, err := Parse(strings.NewReader(robotsTxt), "crawler/1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, 1500*time.Millisecond, r.CrawlDelay())
+
+	r, err = Parse(strings.NewReader(robotsTxt), "somebot")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), r.CrawlDelay())
+}
+
+func TestParseSitemaps(t *testing.T) {
+	r, err := Parse(strings.NewReader(robotsTxt), "somebot/1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://go.test/sitemap.xml", "/news.xml"}, r.Sitemaps())
+	assert.Empty(t, AllowAll().Sitemaps())
+}
+
+func TestAllowAllDisallowAll(t *testing.T) {
+	assert.True(t, AllowAll().Allowed("/any"))
+	assert.False(t, DisallowAll().Allowed("/any"))
+}
+
+func Test_match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/", path: "/anything", want: true},
+		{pattern: "/fish", path: "/fish.html", want: true},
+		{pattern: "/fish", path: "/Fish.html", want: false},
+		{pattern: "/fish*", path: "/fishheads/yummy.html", want: true},
+		{pattern: "/*.php", path: "/folder/filename.php?parameters", want: true},
+		{pattern: "/*.php$", path: "/filename.php/", want: false},
+		{pattern: "/fish*.php", path: "/fishheads/catfish.php?parameters", want: true},
+		{pattern: "/fish*.php", path: "/Fish.PHP", want: false},
+		{pattern: "/a*b*c$", path: "/a-b-b-c", want: true},
+		{pattern: "/a$", path: "/a", want: true},
+		{pattern: "/a$", path: "/ab", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, match(tt.pattern, tt.path))
+		})
+	}
+}
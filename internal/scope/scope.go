@@ -0,0 +1,147 @@
+package scope
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/vfunin/crawler/internal/config"
+
+	"github.com/pkg/errors"
+)
+
+type Scope interface {
+	Contains(uri string) bool
+}
+
+type scope struct {
+	seedHost        string
+	sameHost        bool
+	allowSubdomains bool
+	allowedDomains  []string
+	deniedDomains   []string
+	include         []*regexp.Regexp
+	exclude         []*regexp.Regexp
+}
+
+// New - builds the scope of the crawl started from seed
+func New(cfg config.Scope, seed string) (Scope, error) {
+	u, err := url.Parse(seed)
+	if err != nil {
+		return nil, errors.Wrap(err, "scope seed parsing")
+	}
+
+	s := &scope{
+		seedHost:        strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."),
+		sameHost:        cfg.SameHost,
+		allowSubdomains: cfg.AllowSubdomains,
+		allowedDomains:  normalizeDomains(cfg.AllowedDomains),
+		deniedDomains:   normalizeDomains(cfg.DeniedDomains),
+		include:         nil,
+		exclude:         nil,
+	}
+
+	if s.include, err = compile(cfg.Include); err != nil {
+		return nil, err
+	}
+
+	if s.exclude, err = compile(cfg.Exclude); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Contains - reports whether the link should be crawled
+func (s *scope) Contains(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+
+	if matchDomains(host, s.deniedDomains) {
+		return false
+	}
+
+	if !s.hostAllowed(host) {
+		return false
+	}
+
+	if len(s.include) > 0 && !matchAny(uri, s.include) {
+		return false
+	}
+
+	return !matchAny(uri, s.exclude)
+}
+
+// hostAllowed - the seed host is always allowed, so listing other domains does not take the seed out of the scope
+func (s *scope) hostAllowed(host string) bool {
+	if !s.sameHost && !s.allowSubdomains && len(s.allowedDomains) == 0 {
+		return true
+	}
+
+	if strings.TrimPrefix(host, "www.") == s.seedHost {
+		return true
+	}
+
+	if s.allowSubdomains && isSubdomain(host, s.seedHost) {
+		return true
+	}
+
+	return matchDomains(host, s.allowedDomains)
+}
+
+func matchDomains(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || isSubdomain(host, domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSubdomain(host, domain string) bool {
+	return strings.HasSuffix(host, "."+domain)
+}
+
+func matchAny(uri string, exprs []*regexp.Regexp) bool {
+	for _, expr := range exprs {
+		if expr.MatchString(uri) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeDomains(domains []string) []string {
+	result := make([]string, 0, len(domains))
+
+	for _, domain := range domains {
+		result = append(result, strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."))
+	}
+
+	return result
+}
+
+func compile(exprs []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(exprs))
+
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, errors.Wrap(err, "scope regular expression ("+expr+")")
+		}
+
+		result = append(result, re)
+	}
+
+	return result, nil
+}
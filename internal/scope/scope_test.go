@@ -0,0 +1,90 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New(config.Scope{}, "https://go.test") //nolint:exhaustivestruct
+	assert.Nil(t, err)
+	assert.NotNil(t, s)
+
+	_, err = New(config.Scope{Include: []string{"("}}, "https://go.test") //nolint:exhaustivestruct
+	assert.NotNil(t, err)
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Scope
+		uri  string
+		want bool
+	}{
+		{name: "unrestricted", cfg: config.Scope{}, uri: "https://other.test/", want: true},                             //nolint:exhaustivestruct
+		{name: "unsupported scheme", cfg: config.Scope{}, uri: "mailto:admin@go.test", want: false},                     //nolint:exhaustivestruct
+		{name: "same host", cfg: config.Scope{SameHost: true}, uri: "http://GO.test/page", want: true},                  //nolint:exhaustivestruct
+		{name: "same host with www", cfg: config.Scope{SameHost: true}, uri: "https://www.go.test/", want: true},        //nolint:exhaustivestruct
+		{name: "other host", cfg: config.Scope{SameHost: true}, uri: "https://other.test/", want: false},                //nolint:exhaustivestruct
+		{name: "subdomain denied", cfg: config.Scope{SameHost: true}, uri: "https://blog.go.test/", want: false},        //nolint:exhaustivestruct
+		{name: "subdomain allowed", cfg: config.Scope{AllowSubdomains: true}, uri: "https://blog.go.test/", want: true}, //nolint:exhaustivestruct
+		{
+			name: "allowed domain",
+			cfg:  config.Scope{SameHost: true, AllowedDomains: []string{"cdn.test"}}, //nolint:exhaustivestruct
+			uri:  "https://img.cdn.test/a.png",
+			want: true,
+		},
+		{
+			name: "seed host with allowed domains",
+			cfg:  config.Scope{AllowedDomains: []string{"cdn.test"}}, //nolint:exhaustivestruct
+			uri:  "https://go.test/page",
+			want: true,
+		},
+		{
+			name: "other host with allowed domains",
+			cfg:  config.Scope{AllowedDomains: []string{"cdn.test"}}, //nolint:exhaustivestruct
+			uri:  "https://other.test/",
+			want: false,
+		},
+		{
+			name: "denied seed host",
+			cfg:  config.Scope{AllowedDomains: []string{"cdn.test"}, DeniedDomains: []string{"go.test"}}, //nolint:exhaustivestruct
+			uri:  "https://go.test/page",
+			want: false,
+		},
+		{
+			name: "denied domain",
+			cfg:  config.Scope{DeniedDomains: []string{"facebook.com"}}, //nolint:exhaustivestruct
+			uri:  "https://www.facebook.com/share",
+			want: false,
+		},
+		{
+			name: "include",
+			cfg:  config.Scope{Include: []string{`/docs/`}}, //nolint:exhaustivestruct
+			uri:  "https://go.test/docs/intro",
+			want: true,
+		},
+		{
+			name: "not included",
+			cfg:  config.Scope{Include: []string{`/docs/`}}, //nolint:exhaustivestruct
+			uri:  "https://go.test/blog/intro",
+			want: false,
+		},
+		{
+			name: "excluded",
+			cfg:  config.Scope{Exclude: []string{`\.pdf$`}}, //nolint:exhaustivestruct
+			uri:  "https://go.test/file.pdf",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.cfg, "https://www.go.test/")
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, s.Contains(tt.uri))
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package sitemap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/robots"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// Path - conventional location of the sitemap of a host
+	Path = "/sitemap.xml"
+	// maxSize - size limit of an uncompressed sitemap, the one of the sitemaps protocol
+	maxSize = 50 << 20
+	// maxIndexDepth - nesting of sitemap indexes which is followed
+	maxIndexDepth = 3
+)
+
+// errNotFound - the sitemap is answered with 404 or 410
+var errNotFound = errors.New("sitemap not found") //nolint:gochecknoglobals
+
+// Entry - url listed in a sitemap or a feed
+type Entry struct {
+	URL string
+	// Source - url of the sitemap or the feed listing the url
+	Source string
+}
+
+type Sitemaps interface {
+	Entries(ctx context.Context, seed string) ([]Entry, error)
+}
+
+type Option func(s *sitemaps)
+
+// WithRoundTripper - fetches the sitemaps with rt, e.g. the transport shared with the fetcher
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return func(s *sitemaps) {
+		s.client.Transport = rt
+	}
+}
+
+// WithUserAgent - sends userAgent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(s *sitemaps) {
+		s.userAgent = userAgent
+	}
+}
+
+// WithRobots - discovers the sitemaps listed in robots.txt with r
+func WithRobots(r robots.Robots) Option {
+	return func(s *sitemaps) {
+		s.robots = r
+	}
+}
+
+type sitemaps struct {
+	mu        sync.Mutex
+	cfg       config.Sitemaps
+	client    *http.Client
+	userAgent string
+	robots    robots.Robots
+	read      map[string]struct{}
+}
+
+// source - sitemap to read, guessed ones are skipped silently when they do not exist
+type source struct {
+	url     string
+	guessed bool
+}
+
+// document - root element of a sitemap index, an urlset, an rss or an atom feed
+type document struct {
+	XMLName  xml.Name
+	Sitemaps []location `xml:"sitemap"`
+	URLs     []location `xml:"url"`
+	Channel  channel    `xml:"channel"`
+	// Items - items of rss 1.0 feeds, which are listed next to the channel
+	Items   []item  `xml:"item"`
+	Entries []entry `xml:"entry"`
+}
+
+type location struct {
+	Loc string `xml:"loc"`
+}
+
+type channel struct {
+	Items []item `xml:"item"`
+}
+
+type item struct {
+	Link string `xml:"link"`
+}
+
+type entry struct {
+	Links []link `xml:"link"`
+}
+
+type link struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+func New(cfg config.Sitemaps, timeout time.Duration, opts ...Option) Sitemaps {
+	s := &sitemaps{
+		mu:        sync.Mutex{},
+		cfg:       cfg,
+		client:    &http.Client{Timeout: timeout}, //nolint:exhaustivestruct
+		userAgent: "",
+		robots:    nil,
+		read:      make(map[string]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Entries - returns the urls of the configured sitemaps and, when discovery is enabled, of the sitemaps listed
+// in robots.txt of the seed host and its /sitemap.xml; every sitemap is read once, failed sitemaps are skipped
+// and the first failure is returned with the urls of the other ones
+func (s *sitemaps) Entries(ctx context.Context, seed string) (entries []Entry, err error) {
+	base, err := url.Parse(seed)
+	if err != nil {
+		return nil, errors.Wrap(err, "sitemap seed parsing")
+	}
+
+	for _, src := range s.sources(ctx, base) {
+		found, lErr := s.load(ctx, src.url, 0)
+		if lErr != nil && err == nil && !(src.guessed && errors.Is(lErr, errNotFound)) {
+			err = lErr
+		}
+
+		entries = append(entries, found...)
+	}
+
+	return entries, err
+}
+
+// sources - returns the configured sitemaps resolved against base followed by the discovered ones
+func (s *sitemaps) sources(ctx context.Context, base *url.URL) (sources []source) {
+	for _, raw := range s.cfg.URLs {
+		if ref, err := url.Parse(raw); err == nil {
+			sources = append(sources, source{url: base.ResolveReference(ref).String(), guessed: false})
+		}
+	}
+
+	if !s.cfg.Discover {
+		return sources
+	}
+
+	if s.robots != nil {
+		listed, err := s.robots.Sitemaps(ctx, base.String())
+		if err == nil {
+			for _, u := range listed {
+				sources = append(sources, source{url: u, guessed: false})
+			}
+		}
+	}
+
+	root := &url.URL{Scheme: base.Scheme, Host: base.Host, Path: Path} //nolint:exhaustivestruct
+
+	return append(sources, source{url: root.String(), guessed: true})
+}
+
+// load - reads the sitemap or the feed unless it has been read before, nested sitemaps of an index
+// are read up to maxIndexDepth
+func (s *sitemaps) load(ctx context.Context, uri string, depth int) (entries []Entry, err error) {
+	s.mu.Lock()
+	if _, ok := s.read[uri]; ok {
+		s.mu.Unlock()
+
+		return nil, nil
+	}
+
+	s.read[uri] = struct{}{}
+	s.mu.Unlock()
+
+	doc, base, err := s.fetch(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(doc.XMLName.Local) {
+	case "sitemapindex":
+		if depth >= maxIndexDepth {
+			return nil, errors.New("sitemap index " + uri + " is nested too deep")
+		}
+
+		for _, sitemap := range doc.Sitemaps {
+			nested, ok := resolve(base, sitemap.Loc)
+			if !ok {
+				continue
+			}
+
+			found, lErr := s.load(ctx, nested, depth+1)
+			if lErr != nil && err == nil {
+				err = lErr
+			}
+
+			entries = append(entries, found...)
+		}
+
+		return entries, err
+	case "urlset":
+		for _, u := range doc.URLs {
+			entries = appendEntry(entries, base, u.Loc, uri)
+		}
+	case "rss", "rdf":
+		for _, i := range append(doc.Channel.Items, doc.Items...) {
+			entries = appendEntry(entries, base, i.Link, uri)
+		}
+	case "feed":
+		for _, e := range doc.Entries {
+			entries = appendEntry(entries, base, e.link(), uri)
+		}
+	default:
+		return nil, errors.New(uri + " is not a sitemap or a feed")
+	}
+
+	return entries, nil
+}
+
+// fetch - downloads and decodes the document, gzipped documents are recognized by their content,
+// the url after redirects is returned as the base of relative urls
+func (s *sitemaps) fetch(ctx context.Context, uri string) (doc document, base *url.URL, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return doc, nil, errors.Wrap(err, "sitemap request")
+	}
+
+	if s.userAgent != "" {
+		req.Header.Set("User-Agent", s.userAgent)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return doc, nil, errors.Wrap(err, "sitemap response")
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return doc, nil, errors.Wrap(errNotFound, uri)
+	case resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices:
+		return doc, nil, errors.New("sitemap " + uri + " responded with status " + strconv.Itoa(resp.StatusCode))
+	}
+
+	body := bufio.NewReader(io.LimitReader(resp.Body, maxSize))
+
+	var reader io.Reader = body
+
+	if magic, _ := body.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, gErr := gzip.NewReader(body)
+		if gErr != nil {
+			return doc, nil, errors.Wrap(gErr, "sitemap "+uri+" decompression")
+		}
+		defer gz.Close()
+
+		reader = io.LimitReader(gz, maxSize)
+	}
+
+	decoder := xml.NewDecoder(reader)
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	if err = decoder.Decode(&doc); err != nil {
+		return doc, nil, errors.Wrap(err, "sitemap "+uri+" parsing")
+	}
+
+	return doc, resp.Request.URL, nil
+}
+
+// link - returns the alternate link of the atom entry, the one without a relation is alternate as well
+func (e entry) link() string {
+	for _, l := range e.Links {
+		if l.Rel == "" || strings.EqualFold(l.Rel, "alternate") {
+			return l.Href
+		}
+	}
+
+	return ""
+}
+
+// appendEntry - appends the http url of the sitemap or the feed resolved against base, other urls are skipped
+func appendEntry(entries []Entry, base *url.URL, raw, source string) []Entry {
+	if u, ok := resolve(base, raw); ok {
+		return append(entries, Entry{URL: u, Source: source})
+	}
+
+	return entries
+}
+
+// resolve - returns raw resolved against base if it is an http url
+func resolve(base *url.URL, raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", false
+	}
+
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+
+	u := base.ResolveReference(ref)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+
+	return u.String(), true
+}
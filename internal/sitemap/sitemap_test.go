@@ -0,0 +1,108 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vfunin/crawler/internal/config"
+	"github.com/vfunin/crawler/internal/robots"
+)
+
+const (
+	index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>/pages.xml.gz</loc></sitemap>
+	<sitemap><loc>/index.xml</loc></sitemap>
+</sitemapindex>`
+	urlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc> /about </loc><lastmod>2021-10-21</lastmod></url>
+	<url><loc>mailto:info@go.test</loc></url>
+</urlset>`
+	rss = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>News</title><link>/</link>
+	<item><title>First</title><link>/news/1</link></item>
+</channel></rss>`
+	atom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><link rel="self" href="/atom.xml"/>
+	<entry><link rel="edit" href="/edit/2"/><link href="/news/2"/></entry>
+</feed>`
+)
+
+func TestNew(t *testing.T) {
+	s := New(config.Sitemaps{Discover: true, URLs: nil}, time.Second)
+	assert.NotNil(t, s)
+}
+
+func TestEntries(t *testing.T) {
+	var gz bytes.Buffer
+
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write([]byte(urlset))
+	_ = w.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("User-agent: *\nSitemap: /index.xml\n"))
+	})
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "crawler/1.0", r.UserAgent())
+		_, _ = w.Write([]byte(index))
+	})
+	mux.HandleFunc("/pages.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		_, _ = w.Write(gz.Bytes())
+	})
+	mux.HandleFunc("/rss.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(rss))
+	})
+	mux.HandleFunc("/atom.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(atom))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := config.Sitemaps{Discover: true, URLs: []string{"/rss.xml", server.URL + "/atom.xml"}}
+	s := New(cfg, time.Second, WithUserAgent("crawler/1.0"), WithRobots(robots.New("crawler/1.0", time.Second, nil)))
+
+	entries, err := s.Entries(context.Background(), server.URL+"/")
+	assert.Nil(t, err)
+	assert.Equal(t, []Entry{
+		{URL: server.URL + "/news/1", Source: server.URL + "/rss.xml"},
+		{URL: server.URL + "/news/2", Source: server.URL + "/atom.xml"},
+		{URL: server.URL + "/about", Source: server.URL + "/pages.xml.gz"},
+	}, entries)
+
+	entries, err = s.Entries(context.Background(), server.URL+"/other")
+	assert.Nil(t, err)
+	assert.Empty(t, entries, "sitemaps are read once")
+}
+
+func TestEntriesErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page.html", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body>not a sitemap</body></html>`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	entries, err := New(config.Sitemaps{Discover: true, URLs: nil}, time.Second).Entries(context.Background(), server.URL)
+	assert.Nil(t, err, "a missing /sitemap.xml is not an error")
+	assert.Empty(t, entries)
+
+	_, err = New(config.Sitemaps{Discover: false, URLs: []string{"/sitemap.xml"}}, time.Second).
+		Entries(context.Background(), server.URL)
+	assert.ErrorIs(t, err, errNotFound)
+
+	_, err = New(config.Sitemaps{Discover: false, URLs: []string{"/page.html"}}, time.Second).
+		Entries(context.Background(), server.URL)
+	assert.Error(t, err)
+}
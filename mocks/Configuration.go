@@ -2,13 +2,88 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+	config "github.com/vfunin/crawler/internal/config"
+)
 
 // Configuration is an autogenerated mock type for the Configuration type
 type Configuration struct {
 	mock.Mock
 }
 
+// Canonicalization provides a mock function with given fields:
+func (_m *Configuration) Canonicalization() config.Canonicalization {
+	ret := _m.Called()
+
+	var r0 config.Canonicalization
+	if rf, ok := ret.Get(0).(func() config.Canonicalization); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Canonicalization)
+	}
+
+	return r0
+}
+
+// CheckpointDir provides a mock function with given fields:
+func (_m *Configuration) CheckpointDir() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Columns provides a mock function with given fields:
+func (_m *Configuration) Columns() []string {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// Content provides a mock function with given fields:
+func (_m *Configuration) Content() config.Content {
+	ret := _m.Called()
+
+	var r0 config.Content
+	if rf, ok := ret.Get(0).(func() config.Content); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Content)
+	}
+
+	return r0
+}
+
+// Delimiter provides a mock function with given fields:
+func (_m *Configuration) Delimiter() rune {
+	ret := _m.Called()
+
+	var r0 rune
+	if rf, ok := ret.Get(0).(func() rune); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(rune)
+	}
+
+	return r0
+}
+
 // DepthIncStep provides a mock function with given fields:
 func (_m *Configuration) DepthIncStep() int {
 	ret := _m.Called()
@@ -23,6 +98,90 @@ func (_m *Configuration) DepthIncStep() int {
 	return r0
 }
 
+// Directives provides a mock function with given fields:
+func (_m *Configuration) Directives() config.Directives {
+	ret := _m.Called()
+
+	var r0 config.Directives
+	if rf, ok := ret.Get(0).(func() config.Directives); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Directives)
+	}
+
+	return r0
+}
+
+// Format provides a mock function with given fields:
+func (_m *Configuration) Format() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// IgnoreRobots provides a mock function with given fields:
+func (_m *Configuration) IgnoreRobots() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// LinkCheck provides a mock function with given fields:
+func (_m *Configuration) LinkCheck() config.LinkCheck {
+	ret := _m.Called()
+
+	var r0 config.LinkCheck
+	if rf, ok := ret.Get(0).(func() config.LinkCheck); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.LinkCheck)
+	}
+
+	return r0
+}
+
+// Links provides a mock function with given fields:
+func (_m *Configuration) Links() config.Links {
+	ret := _m.Called()
+
+	var r0 config.Links
+	if rf, ok := ret.Get(0).(func() config.Links); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Links)
+	}
+
+	return r0
+}
+
+// Login provides a mock function with given fields:
+func (_m *Configuration) Login() config.Login {
+	ret := _m.Called()
+
+	var r0 config.Login
+	if rf, ok := ret.Get(0).(func() config.Login); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Login)
+	}
+
+	return r0
+}
+
 // MaxDepth provides a mock function with given fields:
 func (_m *Configuration) MaxDepth() uint64 {
 	ret := _m.Called()
@@ -79,11 +238,162 @@ func (_m *Configuration) OutputToFile() bool {
 	return r0
 }
 
+// Politeness provides a mock function with given fields:
+func (_m *Configuration) Politeness() config.Politeness {
+	ret := _m.Called()
+
+	var r0 config.Politeness
+	if rf, ok := ret.Get(0).(func() config.Politeness); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Politeness)
+	}
+
+	return r0
+}
+
+// Proxy provides a mock function with given fields:
+func (_m *Configuration) Proxy() config.Proxy {
+	ret := _m.Called()
+
+	var r0 config.Proxy
+	if rf, ok := ret.Get(0).(func() config.Proxy); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Proxy)
+	}
+
+	return r0
+}
+
+// Request provides a mock function with given fields:
+func (_m *Configuration) Request() config.Request {
+	ret := _m.Called()
+
+	var r0 config.Request
+	if rf, ok := ret.Get(0).(func() config.Request); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Request)
+	}
+
+	return r0
+}
+
+// Resume provides a mock function with given fields:
+func (_m *Configuration) Resume() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Retry provides a mock function with given fields:
+func (_m *Configuration) Retry() config.Retry {
+	ret := _m.Called()
+
+	var r0 config.Retry
+	if rf, ok := ret.Get(0).(func() config.Retry); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Retry)
+	}
+
+	return r0
+}
+
+// Scope provides a mock function with given fields:
+func (_m *Configuration) Scope() config.Scope {
+	ret := _m.Called()
+
+	var r0 config.Scope
+	if rf, ok := ret.Get(0).(func() config.Scope); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Scope)
+	}
+
+	return r0
+}
+
+// Seeds provides a mock function with given fields:
+func (_m *Configuration) Seeds() []config.Seed {
+	ret := _m.Called()
+
+	var r0 []config.Seed
+	if rf, ok := ret.Get(0).(func() []config.Seed); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]config.Seed)
+		}
+	}
+
+	return r0
+}
+
 // ShowHelp provides a mock function with given fields:
 func (_m *Configuration) ShowHelp() {
 	_m.Called()
 }
 
+// Snapshot provides a mock function with given fields:
+func (_m *Configuration) Snapshot() ([]byte, error) {
+	ret := _m.Called()
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func() []byte); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SitemapOutput provides a mock function with given fields:
+func (_m *Configuration) SitemapOutput() config.SitemapOutput {
+	ret := _m.Called()
+
+	var r0 config.SitemapOutput
+	if rf, ok := ret.Get(0).(func() config.SitemapOutput); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.SitemapOutput)
+	}
+
+	return r0
+}
+
+// Sitemaps provides a mock function with given fields:
+func (_m *Configuration) Sitemaps() config.Sitemaps {
+	ret := _m.Called()
+
+	var r0 config.Sitemaps
+	if rf, ok := ret.Get(0).(func() config.Sitemaps); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Sitemaps)
+	}
+
+	return r0
+}
+
 // String provides a mock function with given fields:
 func (_m *Configuration) String() string {
 	ret := _m.Called()
@@ -98,6 +408,20 @@ func (_m *Configuration) String() string {
 	return r0
 }
 
+// TLS provides a mock function with given fields:
+func (_m *Configuration) TLS() config.TLS {
+	ret := _m.Called()
+
+	var r0 config.TLS
+	if rf, ok := ret.Get(0).(func() config.TLS); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.TLS)
+	}
+
+	return r0
+}
+
 // Timeout provides a mock function with given fields:
 func (_m *Configuration) Timeout() int {
 	ret := _m.Called()
@@ -112,6 +436,20 @@ func (_m *Configuration) Timeout() int {
 	return r0
 }
 
+// Transport provides a mock function with given fields:
+func (_m *Configuration) Transport() config.Transport {
+	ret := _m.Called()
+
+	var r0 config.Transport
+	if rf, ok := ret.Get(0).(func() config.Transport); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(config.Transport)
+	}
+
+	return r0
+}
+
 // URL provides a mock function with given fields:
 func (_m *Configuration) URL() string {
 	ret := _m.Called()
@@ -126,6 +464,20 @@ func (_m *Configuration) URL() string {
 	return r0
 }
 
+// UserAgent provides a mock function with given fields:
+func (_m *Configuration) UserAgent() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // WithPanic provides a mock function with given fields:
 func (_m *Configuration) WithPanic() bool {
 	ret := _m.Called()
@@ -139,3 +491,17 @@ func (_m *Configuration) WithPanic() bool {
 
 	return r0
 }
+
+// Workers provides a mock function with given fields:
+func (_m *Configuration) Workers() int {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
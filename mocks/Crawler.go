@@ -24,6 +24,22 @@ func (_m *Crawler) DecCnt() {
 	_m.Called()
 }
 
+// Done provides a mock function with given fields:
+func (_m *Crawler) Done() <-chan struct{} {
+	ret := _m.Called()
+
+	var r0 <-chan struct{}
+	if rf, ok := ret.Get(0).(func() <-chan struct{}); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	return r0
+}
+
 // GetCnt provides a mock function with given fields:
 func (_m *Crawler) GetCnt() int64 {
 	ret := _m.Called()
@@ -7,7 +7,7 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
-	parser "github.com/vfunin/crawler/internal/parser"
+	fetcher "github.com/vfunin/crawler/internal/fetcher"
 )
 
 // Fetcher is an autogenerated mock type for the Fetcher type
@@ -16,15 +16,15 @@ type Fetcher struct {
 }
 
 // Fetch provides a mock function with given fields: ctx, url
-func (_m *Fetcher) Fetch(ctx context.Context, url string) (parser.Page, error) {
+func (_m *Fetcher) Fetch(ctx context.Context, url string) (*fetcher.Response, error) {
 	ret := _m.Called(ctx, url)
 
-	var r0 parser.Page
-	if rf, ok := ret.Get(0).(func(context.Context, string) parser.Page); ok {
+	var r0 *fetcher.Response
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fetcher.Response); ok {
 		r0 = rf(ctx, url)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(parser.Page)
+			r0 = ret.Get(0).(*fetcher.Response)
 		}
 	}
 
@@ -37,3 +37,40 @@ func (_m *Fetcher) Fetch(ctx context.Context, url string) (parser.Page, error) {
 
 	return r0, r1
 }
+
+// Head provides a mock function with given fields: ctx, url
+func (_m *Fetcher) Head(ctx context.Context, url string) (*fetcher.Response, error) {
+	ret := _m.Called(ctx, url)
+
+	var r0 *fetcher.Response
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fetcher.Response); ok {
+		r0 = rf(ctx, url)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fetcher.Response)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, url)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Login provides a mock function with given fields: ctx
+func (_m *Fetcher) Login(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -14,6 +14,36 @@ type Page struct {
 	mock.Mock
 }
 
+// Anchors provides a mock function with given fields:
+func (_m *Page) Anchors() []parser.Link {
+	ret := _m.Called()
+
+	var r0 []parser.Link
+	if rf, ok := ret.Get(0).(func() []parser.Link); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]parser.Link)
+		}
+	}
+
+	return r0
+}
+
+// Canonical provides a mock function with given fields:
+func (_m *Page) Canonical() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // Links provides a mock function with given fields:
 func (_m *Page) Links() []string {
 	ret := _m.Called()
@@ -53,6 +83,20 @@ func (_m *Page) Parse(url string, reader io.Reader) (parser.Page, error) {
 	return r0, r1
 }
 
+// Robots provides a mock function with given fields:
+func (_m *Page) Robots() parser.Directives {
+	ret := _m.Called()
+
+	var r0 parser.Directives
+	if rf, ok := ret.Get(0).(func() parser.Directives); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(parser.Directives)
+	}
+
+	return r0
+}
+
 // Title provides a mock function with given fields:
 func (_m *Page) Title() string {
 	ret := _m.Called()